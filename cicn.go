@@ -50,47 +50,24 @@ func CicnFromBytes(b []byte) (img image.Image, err error) {
 		for y := 0; y < int(rect.height); y++ {
 			idx := uint32(y)*uint32(pixelMap.rowBytes&0x3FFF)*8/uint32(pixelMap.pixelSize) + uint32(x)
 
-			var col uint16
-			switch pixelMap.pixelSize {
-			case 1:
-				col = uint16(pixelMapImageData[idx/8])
-				col &= 0x80 >> (idx % 8)
-				col >>= 7 - (idx % 8)
-			case 2:
-				col = uint16(pixelMapImageData[idx/4])
-				switch idx % 4 {
-				case 0:
-					col >>= 2
-					fallthrough
-				case 1:
-					col >>= 2
-					fallthrough
-				case 2:
-					col >>= 2
-					fallthrough
-				case 3:
-					col &= 0x03
-				}
-			case 4:
-				col = uint16(pixelMapImageData[idx/2])
-				if idx%2 == 1 {
-					col &= 0x0F
-				} else {
-					col = (col & 0xF0) >> 4
-				}
-			case 8:
-				col = uint16(pixelMapImageData[idx])
-			default:
-				return nil, errors.New(fmt.Sprintf("unhandled pixel size: %v", pixelMap.pixelSize))
+			col, err := readIndexedPixel(pixelMapImageData, idx, pixelMap.pixelSize)
+			if err != nil {
+				return nil, err
+			}
+
+			// Pixels outside of the mask are left fully transparent, and each channel takes the low
+			// byte of its color table component.
+			if readBit(maskBitMapImageData, maskBitMap.rowBytes, x, y) == 0 {
+				continue
 			}
 
 			for i := uint16(0); i < colorTable.size; i++ {
 				if colorTable.data[i].value == col {
-					imgRGBA.Set(x, y, color.RGBA{
+					imgRGBA.SetNRGBA(x, y, color.NRGBA{
 						R: uint8(colorTable.data[i].r),
 						G: uint8(colorTable.data[i].g),
 						B: uint8(colorTable.data[i].b),
-						A: ((maskBitMapImageData[idx/8] >> uint8(7-idx%8)) & 0x1) * 255,
+						A: 0xFF,
 					})
 					break
 				}
@@ -2,6 +2,8 @@ package gomacimage
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"image/png"
 	"io/ioutil"
 	"os"
@@ -43,6 +45,7 @@ func TestCicnFromBytes(t *testing.T) {
 		{name: "20000"},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
@@ -80,3 +83,68 @@ func TestCicnFromBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestCicnFromBytes_Colors(t *testing.T) {
+	var (
+		w    dataStructureWrite
+		rect = image.Rect(0, 0, 8, 1)
+	)
+
+	// A 1-bit pixmap of 8x1 pixels.
+	w.writeDWord(0)
+	w.writeWord(0x8001)
+	w.writeQDRect(rect)
+	w.writeWord(0)
+	w.writeWord(0)
+	w.writeDWord(0)
+	w.writeDWord(0x00480000)
+	w.writeDWord(0x00480000)
+	w.writeWord(0)
+	w.writeWord(1)
+	w.writeWord(1)
+	w.writeWord(1)
+	w.writeDWord(0)
+	w.writeDWord(0)
+	w.writeDWord(0)
+
+	// The mask and icon bitmaps, then their data, leaving the last pixel outside of the mask.
+	for i := 0; i < 2; i++ {
+		w.writeDWord(0)
+		w.writeWord(1)
+		w.writeQDRect(rect)
+	}
+	w.writeDWord(0)
+	w.writeData([]byte{0xFE, 0x00})
+
+	// Components whose high and low bytes differ.
+	w.writeDWord(0)
+	w.writeWord(0)
+	w.writeWord(1)
+	w.writeData([]byte{0, 0, 0xFF, 0x00, 0x80, 0x11, 0x00, 0xFF})
+	w.writeData([]byte{0, 1, 0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC})
+
+	w.writeData([]byte{0x0F})
+
+	got, err := CicnFromBytes(w.b.Bytes())
+	if err != nil {
+		t.Fatalf("CicnFromBytes() error = %v", err)
+	}
+
+	// Every pixel is compared exactly: channels come from the low byte of each component, and pixels
+	// outside of the mask are cleared entirely.
+	want := []color.NRGBA{
+		{R: 0x00, G: 0x11, B: 0xFF, A: 0xFF},
+		{R: 0x00, G: 0x11, B: 0xFF, A: 0xFF},
+		{R: 0x00, G: 0x11, B: 0xFF, A: 0xFF},
+		{R: 0x00, G: 0x11, B: 0xFF, A: 0xFF},
+		{R: 0x34, G: 0x78, B: 0xBC, A: 0xFF},
+		{R: 0x34, G: 0x78, B: 0xBC, A: 0xFF},
+		{R: 0x34, G: 0x78, B: 0xBC, A: 0xFF},
+		{},
+	}
+	for x, c := range want {
+		if got := got.At(x, 0); got != c {
+			t.Errorf("CicnFromBytes() [At(%v, 0)] = %v, want %v", x, got, c)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+)
+
+// CicnEncodeOptions are the encoding parameters for CicnEncode.
+type CicnEncodeOptions struct {
+	// Depth is the pixel depth of the icon: 1, 2, 4 or 8. When zero, the smallest depth that holds
+	// every color of the image is used, falling back to 8.
+	Depth int
+
+	// Palette, when set, becomes the color table of the icon instead of one built from the image.
+	Palette color.Palette
+}
+
+// CicnEncode builds a cicn resource from img. The color table holds the colors of the image when they
+// fit within the depth, otherwise its most frequent colors, with every pixel mapped to the nearest
+// entry. Pixels that are not fully opaque are left out of the mask, and the 1-bit icon shown by
+// black and white displays holds the darker half of the opaque pixels.
+func CicnEncode(img image.Image, opts *CicnEncodeOptions) ([]byte, error) {
+	var (
+		bounds = img.Bounds()
+		width  = bounds.Dx()
+		height = bounds.Dy()
+	)
+
+	if bounds.Empty() {
+		return nil, errors.New("cannot encode an empty image as a cicn")
+	}
+
+	var depth int
+	var pal color.Palette
+	if opts != nil {
+		depth = opts.Depth
+		pal = opts.Palette
+	}
+
+	if pal == nil {
+		pal = paletteFromImage(img, depth)
+	}
+	if depth == 0 {
+		depth = 1
+		for depth < 8 && 1<<uint(depth) < len(pal) {
+			depth *= 2
+		}
+	}
+
+	switch depth {
+	case 1, 2, 4, 8:
+	default:
+		return nil, errors.New(fmt.Sprintf("unhandled pixel size: %v", depth))
+	}
+	if len(pal) == 0 || len(pal) > 1<<uint(depth) {
+		return nil, errors.New(fmt.Sprintf("palette of %v colors does not fit in %v bits per pixel", len(pal), depth))
+	}
+
+	// Rows are padded to an even number of bytes. The pixel rows hold as many pixels as the mask rows.
+	var (
+		maskRowBytes  = (width + 15) / 16 * 2
+		pixelRowBytes = maskRowBytes * depth
+		maskData      = make([]uint8, maskRowBytes*height)
+		iconData      = make([]uint8, maskRowBytes*height)
+		pixelData     = make([]uint8, pixelRowBytes*height)
+	)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			if c.A != 0xFF {
+				continue
+			}
+
+			bit := uint8(0x80) >> uint(x%8)
+			maskData[y*maskRowBytes+x/8] |= bit
+
+			if uint32(c.R)*299+uint32(c.G)*587+uint32(c.B)*114 < 128*1000 {
+				iconData[y*maskRowBytes+x/8] |= bit
+			}
+
+			idx := y*pixelRowBytes*8/depth + x
+			perByte := 8 / depth
+			shift := uint(8 - depth*(idx%perByte+1))
+			pixelData[idx/perByte] |= uint8(pal.Index(c)) << shift
+		}
+	}
+
+	var (
+		out  dataStructureWrite
+		rect = image.Rect(0, 0, width, height)
+	)
+
+	// PixMap
+	out.writeDWord(0) // base address
+	out.writeWord(0x8000 | uint16(pixelRowBytes))
+	out.writeQDRect(rect)
+	out.writeWord(0)  // pmVersion
+	out.writeWord(0)  // packType
+	out.writeDWord(0) // packSize
+	out.writeDWord(0x00480000)
+	out.writeDWord(0x00480000)
+	out.writeWord(0) // indexed
+	out.writeWord(uint16(depth))
+	out.writeWord(1) // cmpCount
+	out.writeWord(uint16(depth))
+	out.writeDWord(0) // planeBytes
+	out.writeDWord(0) // pmTable
+	out.writeDWord(0) // pmReserved
+
+	// Mask and icon BitMaps
+	for i := 0; i < 2; i++ {
+		out.writeDWord(0)
+		out.writeWord(uint16(maskRowBytes))
+		out.writeQDRect(rect)
+	}
+
+	out.writeDWord(0) // icon data handle
+	out.writeData(maskData)
+	out.writeData(iconData)
+
+	// Color table
+	out.writeDWord(0) // seed
+	out.writeWord(0)  // flags
+	out.writeWord(uint16(len(pal) - 1))
+	for i, c := range pal {
+		r, g, b, _ := c.RGBA()
+		out.writeWord(uint16(i))
+		out.writeWord(uint16(r))
+		out.writeWord(uint16(g))
+		out.writeWord(uint16(b))
+	}
+
+	out.writeData(pixelData)
+
+	return out.b.Bytes(), nil
+}
+
+// paletteFromImage collects the opaque colors of img, ordered by how often they occur. When a depth
+// is given and there are more colors than it can hold only the most frequent are kept, and without a
+// depth up to 256 are kept.
+func paletteFromImage(img image.Image, depth int) color.Palette {
+	var limit = 256
+	if depth > 0 && depth <= 8 {
+		limit = 1 << uint(depth)
+	}
+	return paletteFromImages([]image.Image{img}, limit)
+}
+
+// paletteFromImages collects the opaque colors of every image, keeping at most limit of the most
+// frequent. The palette always holds at least one color.
+func paletteFromImages(imgs []image.Image, limit int) color.Palette {
+	var (
+		counts = map[color.NRGBA]int{}
+		order  []color.NRGBA
+	)
+
+	for _, img := range imgs {
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				if c.A != 0xFF {
+					continue
+				}
+				if counts[c] == 0 {
+					order = append(order, c)
+				}
+				counts[c]++
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+
+	if len(order) > limit {
+		order = order[:limit]
+	}
+	if len(order) == 0 {
+		order = append(order, color.NRGBA{A: 0xFF})
+	}
+
+	var pal = make(color.Palette, len(order))
+	for i, c := range order {
+		pal[i] = c
+	}
+	return pal
+}
@@ -0,0 +1,116 @@
+package gomacimage
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCicnEncode(t *testing.T) {
+	colors := []color.NRGBA{
+		{R: 0xFF, A: 0xFF},
+		{G: 0x80, A: 0xFF},
+		{R: 0x12, G: 0x34, B: 0x56, A: 0xFF},
+		{},
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			img.SetNRGBA(x, y, colors[(x+y)%len(colors)])
+		}
+	}
+
+	tests := []struct {
+		name  string
+		img   image.Image
+		opts  *CicnEncodeOptions
+		depth uint16
+	}{
+		{name: "smallest depth", img: img, depth: 2},
+		{name: "8-bit", img: img, opts: &CicnEncodeOptions{Depth: 8}, depth: 8},
+		{name: "4-bit", img: img, opts: &CicnEncodeOptions{Depth: 4}, depth: 4},
+		{name: "palette", img: img, opts: &CicnEncodeOptions{Palette: color.Palette{
+			color.Black, colors[0], colors[1], colors[2], color.White,
+		}}, depth: 4},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := CicnEncode(tt.img, tt.opts)
+			if err != nil {
+				t.Fatalf("CicnEncode() error = %v", err)
+			}
+
+			parser := dataStructureParse{d: NewBigEndianDataView(b)}
+			if px := parser.parsePixMap(); px.pixelSize != tt.depth {
+				t.Errorf("CicnEncode() pixel size = %v, want %v", px.pixelSize, tt.depth)
+			}
+
+			got, err := CicnFromBytes(b)
+			if err != nil {
+				t.Fatalf("CicnFromBytes() error = %v", err)
+			}
+
+			_, _, errs := fuzzyCompImage(got, tt.img)
+			for _, err := range errs {
+				t.Errorf("fuzzyCompImage() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCicnEncode_Errors(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 0xFF, A: 0xFF})
+
+	if _, err := CicnEncode(img, &CicnEncodeOptions{Depth: 3}); err == nil {
+		t.Errorf("CicnEncode() error = nil, want an error for a depth of 3")
+	}
+
+	pal := make(color.Palette, 3)
+	for i := range pal {
+		pal[i] = color.Gray{Y: uint8(i)}
+	}
+	if _, err := CicnEncode(img, &CicnEncodeOptions{Depth: 1, Palette: pal}); err == nil {
+		t.Errorf("CicnEncode() error = nil, want an error for a palette larger than the depth")
+	}
+}
+
+func TestCicnEncode_Fixtures(t *testing.T) {
+	for _, name := range []string{"10000", "15000", "20000"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			binaryData, err := ioutil.ReadFile(fmt.Sprintf("test/fixtures/cicn/%s.bin", name))
+			if err != nil {
+				t.Fatalf("ioutil.ReadFile() error = %v", err)
+			}
+
+			want, err := CicnFromBytes(binaryData)
+			if err != nil {
+				t.Fatalf("CicnFromBytes() error = %v", err)
+			}
+
+			b, err := CicnEncode(want, nil)
+			if err != nil {
+				t.Fatalf("CicnEncode() error = %v", err)
+			}
+
+			got, err := CicnFromBytes(b)
+			if err != nil {
+				t.Fatalf("CicnFromBytes() error = %v", err)
+			}
+
+			_, _, errs := fuzzyCompImage(got, want)
+			for _, err := range errs {
+				t.Errorf("fuzzyCompImage() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Clut is a decoded clut resource.
+type Clut struct {
+	// Palette holds the colors of the table, indexed by pixel value.
+	Palette color.Palette
+
+	Seed  uint32
+	Flags uint16
+}
+
+// IsDevice reports whether the table is a device color table, whose entries are indexed by position
+// rather than by the value stored with them.
+func (c *Clut) IsDevice() bool {
+	return c.Flags&0x8000 != 0
+}
+
+// ClutFromBytes decodes a clut resource, a color table as found in PixMaps.
+func ClutFromBytes(b []byte) (clut *Clut, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			clut = nil
+
+			switch x := r.(type) {
+			case string:
+				err = errors.New(x)
+			case error:
+				err = x
+			default:
+				err = errors.New(fmt.Sprintf("unknown panic: %v", r))
+			}
+		}
+	}()
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	ct := parser.parseColorTable()
+
+	return &Clut{
+		Palette: ct.palette(),
+		Seed:    ct.seed,
+		Flags:   ct.flags,
+	}, nil
+}
+
+// PlttEntry holds the Palette Manager settings of one color of a pltt resource.
+type PlttEntry struct {
+	Color     color.Color
+	Usage     uint16
+	Tolerance uint16
+}
+
+// Pltt is a decoded pltt resource.
+type Pltt struct {
+	// Palette holds the colors of the entries, in order.
+	Palette color.Palette
+	Entries []PlttEntry
+}
+
+// PlttFromBytes decodes a pltt resource, a Palette Manager palette.
+func PlttFromBytes(b []byte) (pltt *Pltt, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pltt = nil
+
+			switch x := r.(type) {
+			case string:
+				err = errors.New(x)
+			case error:
+				err = x
+			default:
+				err = errors.New(fmt.Sprintf("unknown panic: %v", r))
+			}
+		}
+	}()
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	count := int(parser.readWord())
+
+	// The rest of the header is only used by the Palette Manager once the palette is loaded.
+	_ = parser.readDataUint8(14)
+
+	pltt = &Pltt{
+		Palette: make(color.Palette, count),
+		Entries: make([]PlttEntry, count),
+	}
+
+	for i := 0; i < count; i++ {
+		row := colorRow{
+			r: parser.readWord(),
+			g: parser.readWord(),
+			b: parser.readWord(),
+		}
+
+		pltt.Palette[i] = row.nrgba()
+		pltt.Entries[i] = PlttEntry{
+			Color:     pltt.Palette[i],
+			Usage:     parser.readWord(),
+			Tolerance: parser.readWord(),
+		}
+
+		// Private fields.
+		_ = parser.readDataUint8(6)
+	}
+
+	return pltt, nil
+}
+
+// PaletteSwatch draws the colors of pal as a grid of squares, size pixels wide and high, with columns
+// colors in each row. When columns is zero, 16 are used.
+func PaletteSwatch(pal color.Palette, columns int, size int) image.Image {
+	if columns <= 0 {
+		columns = 16
+	}
+
+	var (
+		rows = (len(pal) + columns - 1) / columns
+		img  = image.NewNRGBA(image.Rect(0, 0, columns*size, rows*size))
+	)
+
+	for i, c := range pal {
+		cell := image.Rect(0, 0, size, size).Add(image.Pt(i%columns*size, i/columns*size))
+		draw.Draw(img, cell, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	return img
+}
@@ -0,0 +1,100 @@
+package gomacimage
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestClutFromBytes(t *testing.T) {
+	var w dataStructureWrite
+	w.writeDWord(0x1234)
+	w.writeWord(0x8000)
+	w.writeWord(2)
+	for _, row := range []colorRow{{r: 0xFFFF}, {g: 0xFFFF}, {b: 0xFFFF}} {
+		w.writeWord(0)
+		w.writeWord(row.r)
+		w.writeWord(row.g)
+		w.writeWord(row.b)
+	}
+
+	got, err := ClutFromBytes(w.b.Bytes())
+	if err != nil {
+		t.Fatalf("ClutFromBytes() error = %v", err)
+	}
+
+	want := color.Palette{
+		color.NRGBA{R: 0xFF, A: 0xFF},
+		color.NRGBA{G: 0xFF, A: 0xFF},
+		color.NRGBA{B: 0xFF, A: 0xFF},
+	}
+	if !reflect.DeepEqual(got.Palette, want) {
+		t.Errorf("ClutFromBytes() palette = %v, want %v", got.Palette, want)
+	}
+	if got.Seed != 0x1234 || !got.IsDevice() {
+		t.Errorf("ClutFromBytes() seed = %v, flags = %#x", got.Seed, got.Flags)
+	}
+
+	if _, err := ClutFromBytes(w.b.Bytes()[:20]); err == nil {
+		t.Errorf("ClutFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestPlttFromBytes(t *testing.T) {
+	entries := []PlttEntry{
+		{Color: color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}, Usage: 0x0002, Tolerance: 0},
+		{Color: color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xFF}, Usage: 0x0004, Tolerance: 0x1000},
+		{Color: color.NRGBA{A: 0xFF}, Usage: 0x0020, Tolerance: 0xFFFF},
+	}
+
+	var w dataStructureWrite
+	w.writeWord(uint16(len(entries)))
+	w.writeData(make([]byte, 14))
+	for _, e := range entries {
+		r, g, b, _ := e.Color.RGBA()
+		w.writeWord(uint16(r))
+		w.writeWord(uint16(g))
+		w.writeWord(uint16(b))
+		w.writeWord(e.Usage)
+		w.writeWord(e.Tolerance)
+		w.writeData(make([]byte, 6))
+	}
+
+	got, err := PlttFromBytes(w.b.Bytes())
+	if err != nil {
+		t.Fatalf("PlttFromBytes() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Entries, entries) {
+		t.Errorf("PlttFromBytes() entries = %v, want %v", got.Entries, entries)
+	}
+	for i, e := range entries {
+		if got.Palette[i] != e.Color {
+			t.Errorf("PlttFromBytes() palette[%v] = %v, want %v", i, got.Palette[i], e.Color)
+		}
+	}
+
+	if _, err := PlttFromBytes(w.b.Bytes()[:40]); err == nil {
+		t.Errorf("PlttFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestPaletteSwatch(t *testing.T) {
+	got := PaletteSwatch(SystemPalette4, 4, 3)
+
+	if got.Bounds() != image.Rect(0, 0, 12, 12) {
+		t.Fatalf("PaletteSwatch() bounds = %v, want %v", got.Bounds(), image.Rect(0, 0, 12, 12))
+	}
+
+	for i, c := range SystemPalette4 {
+		x, y := i%4*3+2, i/4*3+1
+		if color.NRGBAModel.Convert(got.At(x, y)) != color.NRGBAModel.Convert(c) {
+			t.Errorf("PaletteSwatch().At(%v, %v) = %v, want %v", x, y, got.At(x, y), c)
+		}
+	}
+
+	if got := PaletteSwatch(SystemPalette8, 0, 2); got.Bounds() != image.Rect(0, 0, 32, 32) {
+		t.Errorf("PaletteSwatch() bounds = %v, want %v", got.Bounds(), image.Rect(0, 0, 32, 32))
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image/png"
 	"io/ioutil"
@@ -12,10 +13,17 @@ import (
 )
 
 func main() {
-	if len(os.Args) != 2 {
+	anim := flag.String("anim", "", "write the frames as an animation instead of a sprite sheet: gif or apng")
+	delay := flag.Duration("delay", gomacimage.DefaultRleFrameDelay, "delay between animation frames")
+	first := flag.Int("first", 0, "first frame of the animation")
+	count := flag.Int("count", 0, "number of frames in the animation, all remaining frames when 0")
+	out := flag.String("o", "", "output path, test/fixtures/rle/<id>.png, .gif or .apng by default")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
 		log.Fatal("need an id as second arg")
 	}
-	strId := os.Args[1]
+	strId := flag.Arg(0)
 	id, err := strconv.Atoi(strId)
 	if err != nil {
 		log.Fatal(err)
@@ -31,7 +39,47 @@ func main() {
 		log.Fatalf("RleFromBytes() error = %v", err)
 	}
 
-	o, _ := os.OpenFile(fmt.Sprintf("test/fixtures/rle/%d.png", id), os.O_WRONLY|os.O_CREATE, 0600)
+	opts := &gomacimage.RleAnimationOptions{
+		Delay:      *delay,
+		FirstFrame: *first,
+		FrameCount: *count,
+	}
+
+	var ext string
+	switch *anim {
+	case "":
+		ext = "png"
+	case "gif", "apng":
+		ext = *anim
+	default:
+		log.Fatalf("unknown animation format: %v", *anim)
+	}
+
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("test/fixtures/rle/%d.%s", id, ext)
+	}
+
+	o, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
 	defer o.Close()
-	png.Encode(o, want.Image)
+
+	switch *anim {
+	case "":
+		if err := png.Encode(o, want.Image); err != nil {
+			log.Fatalf("png.Encode() error = %v", err)
+		}
+
+	case "gif":
+		if err := gomacimage.RleEncodeGIF(o, want, opts); err != nil {
+			log.Fatalf("RleEncodeGIF() error = %v", err)
+		}
+
+	case "apng":
+		if err := gomacimage.RleEncodeAPNG(o, want, opts); err != nil {
+			log.Fatalf("RleEncodeAPNG() error = %v", err)
+		}
+	}
 }
@@ -5,36 +5,87 @@ import (
 	"image/png"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/imle/resourcefork"
 
 	"github.com/imle/gomacimage"
+	"github.com/imle/gomacimage/macbinary"
 )
 
+// Usage:
+//
+//	res2png <id>                 converts an rlëD from the Nova Files
+//	res2png <file> [id]          converts one or every rlëD of a resource fork or MacBinary file
 func main() {
-	if len(os.Args) != 2 {
-		log.Fatal("need an id as second arg")
+	if len(os.Args) < 2 || len(os.Args) > 3 {
+		log.Fatal("need an id, or a file and an optional id, as args")
 	}
-	strId := os.Args[1]
-	id, err := strconv.Atoi(strId)
+
+	// A lone number is an id from the Nova Files, unless a file of that name exists.
+	if id, err := strconv.Atoi(os.Args[1]); err == nil && len(os.Args) == 2 && !fileExists(os.Args[1]) {
+		rf, err := resourcefork.ReadResourceForkFromPath("./assets/Nova Files")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		writeRle(rf, uint16(id), fmt.Sprintf("test/fixtures/rle/%d.png", id))
+		return
+	}
+
+	path := os.Args[1]
+	b, err := macbinary.ResourceForkFromPath(path)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	rf, err := resourcefork.ReadResourceForkFromPath("./assets/Nova Files")
+	rf, err := resourcefork.ReadResourceForkFromBytes(b)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	res := rf.Resources["rlëD"][uint16(id)]
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if len(os.Args) == 3 {
+		id, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		writeRle(rf, uint16(id), fmt.Sprintf("%s-%d.png", base, id))
+		return
+	}
+
+	for id := range rf.Resources["rlëD"] {
+		writeRle(rf, id, fmt.Sprintf("%s-%d.png", base, id))
+	}
+}
+
+func writeRle(rf *resourcefork.ResourceFork, id uint16, out string) {
+	res, ok := rf.Resources["rlëD"][id]
+	if !ok {
+		log.Fatalf("no rlëD resource with id %d", id)
+	}
 
 	want, err := gomacimage.RleFromBytes(res.Data)
 	if err != nil {
 		log.Fatalf("RleFromBytes() error = %v", err)
 	}
 
-	o, _ := os.OpenFile(fmt.Sprintf("test/fixtures/rle/%d.png", id), os.O_WRONLY|os.O_CREATE, 0600)
+	o, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
 	defer o.Close()
-	png.Encode(o, want.Image)
+
+	if err := png.Encode(o, want.Image); err != nil {
+		log.Fatalf("png.Encode() error = %v", err)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
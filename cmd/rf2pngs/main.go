@@ -9,15 +9,26 @@ import (
 	"github.com/imle/resourcefork"
 
 	"github.com/imle/gomacimage"
+	"github.com/imle/gomacimage/macbinary"
 )
 
 func main() {
 	dir := "test/fixtures/rle2"
-	if len(os.Args) == 2 {
+	if len(os.Args) >= 2 {
 		dir = os.Args[1]
 	}
 
-	rf, err := resourcefork.ReadResourceForkFromPath("./assets/Nova Files")
+	// An optional resource fork or MacBinary file replaces the Nova Files.
+	var rf *resourcefork.ResourceFork
+	var err error
+	if len(os.Args) == 3 {
+		var b []byte
+		if b, err = macbinary.ResourceForkFromPath(os.Args[2]); err == nil {
+			rf, err = resourcefork.ReadResourceForkFromBytes(b)
+		}
+	} else {
+		rf, err = resourcefork.ReadResourceForkFromPath("./assets/Nova Files")
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -30,9 +41,15 @@ func main() {
 				log.Fatalf("RleFromBytes() error = %v", err)
 			}
 
-			o, _ := os.OpenFile(fmt.Sprintf("%s/%d.png", dir, id), os.O_WRONLY|os.O_CREATE, 0600)
+			o, err := os.OpenFile(fmt.Sprintf("%s/%d.png", dir, id), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				log.Fatal(err)
+			}
 			defer o.Close()
-			_ = png.Encode(o, want.Image)
+
+			if err := png.Encode(o, want.Image); err != nil {
+				log.Fatalf("png.Encode() error = %v", err)
+			}
 		}()
 	}
 }
@@ -0,0 +1,147 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	cursorSize     = 16
+	cursorRowBytes = cursorSize / 8
+	cursorDataSize = cursorRowBytes * cursorSize
+)
+
+// Cursor is a decoded CURS or crsr cursor.
+//
+// Each pixel of a cursor combines a data bit with a mask bit. Pixels inside the mask are drawn in
+// their color, while pixels outside of it are either transparent or, when their data bit is set,
+// invert whatever lies beneath the cursor.
+type Cursor struct {
+	// Image holds the drawn pixels of the cursor, and is transparent elsewhere.
+	Image image.Image
+
+	// Mask is opaque where the cursor draws its own pixels.
+	Mask *image.Alpha
+
+	// Invert is opaque where the cursor inverts the pixels beneath it.
+	Invert *image.Alpha
+
+	// Hotspot is the point within the cursor that marks the mouse location.
+	Hotspot image.Point
+}
+
+// CursFromBytes decodes a CURS resource, a 16x16 black and white cursor with its mask and hotspot.
+func CursFromBytes(b []byte) (*Cursor, error) {
+	if len(b) < 2*cursorDataSize+4 {
+		return nil, errors.New(fmt.Sprintf("CURS resource is %v bytes, expected %v", len(b), 2*cursorDataSize+4))
+	}
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	var (
+		data     = parser.readDataUint8(cursorDataSize)
+		maskData = parser.readDataUint8(cursorDataSize)
+		hotspot  = parser.readQDPoint()
+	)
+
+	return &Cursor{
+		Image:   bitMapImage(data, maskData, cursorRowBytes, cursorSize, cursorSize),
+		Mask:    bitMapMask(maskData, cursorRowBytes, cursorSize, cursorSize),
+		Invert:  cursorInvertMask(data, maskData),
+		Hotspot: hotspot,
+	}, nil
+}
+
+// CrsrFromBytes decodes a crsr resource, a color cursor. Like a cicn it holds a PixMap and color table,
+// found here through offsets within the resource, along with the black and white cursor used on 1-bit
+// screens. The mask and inverted pixels come from the black and white cursor.
+func CrsrFromBytes(b []byte) (cursor *Cursor, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			cursor = nil
+
+			switch x := r.(type) {
+			case string:
+				err = errors.New(x)
+			case error:
+				err = x
+			default:
+				err = errors.New(fmt.Sprintf("unknown panic: %v", r))
+			}
+		}
+	}()
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	var (
+		_        = parser.readWord() // crsrType
+		crsrMap  = parser.readDWord()
+		crsrData = parser.readDWord()
+		_        = parser.readDWord() // crsrXData
+		_        = parser.readWord()  // crsrXValid
+		_        = parser.readDWord() // crsrXHandle
+		data     = parser.readDataUint8(cursorDataSize)
+		maskData = parser.readDataUint8(cursorDataSize)
+		hotspot  = parser.readQDPoint()
+	)
+
+	parser.pos = int(crsrMap)
+	pixelMap := parser.parsePixMap()
+
+	parser.pos = int(pixelMap.pmTable)
+	colorTable := parser.parseColorTable()
+
+	parser.pos = int(crsrData)
+	rows, err := parser.readPackedRows(pixelMap.rowBytes, pixelMap.bounds.height, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pixels, err := decodeIndexedRows(pixelMap, colorTable, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rect = pixels.Bounds()
+		img  = image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	)
+
+	for y := 0; y < rect.Dy() && y < cursorSize; y++ {
+		for x := 0; x < rect.Dx() && x < cursorSize; x++ {
+			if readBit(maskData, cursorRowBytes, x, y) == 1 {
+				img.SetNRGBA(x, y, pixels.NRGBAAt(rect.Min.X+x, rect.Min.Y+y))
+			}
+		}
+	}
+
+	return &Cursor{
+		Image:   img,
+		Mask:    bitMapMask(maskData, cursorRowBytes, cursorSize, cursorSize),
+		Invert:  cursorInvertMask(data, maskData),
+		Hotspot: hotspot,
+	}, nil
+}
+
+// cursorInvertMask marks the pixels whose data bit is set outside of the mask.
+func cursorInvertMask(data []uint8, maskData []uint8) *image.Alpha {
+	var invert = image.NewAlpha(image.Rect(0, 0, cursorSize, cursorSize))
+
+	for y := 0; y < cursorSize; y++ {
+		for x := 0; x < cursorSize; x++ {
+			if readBit(data, cursorRowBytes, x, y) == 1 && readBit(maskData, cursorRowBytes, x, y) == 0 {
+				invert.SetAlpha(x, y, color.Alpha{A: 0xFF})
+			}
+		}
+	}
+
+	return invert
+}
@@ -0,0 +1,159 @@
+package gomacimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// In the test cursor the mask covers columns 0-7, the data covers columns 4-11,
+// so columns 4-7 are black, 0-3 are white and 8-11 invert what is beneath.
+func testCursorBits(t *testing.T) (data []byte, mask []byte) {
+	t.Helper()
+
+	data = testIconPixels(image.Pt(16, 16), 1, func(x, y int) uint8 {
+		if x >= 4 && x < 12 {
+			return 1
+		}
+		return 0
+	})
+	mask = testIconPixels(image.Pt(16, 16), 1, func(x, y int) uint8 {
+		if x < 8 {
+			return 1
+		}
+		return 0
+	})
+	return data, mask
+}
+
+func checkCursorMasks(t *testing.T, got *Cursor) {
+	t.Helper()
+
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			wantMask := x < 8
+			wantInvert := x >= 8 && x < 12
+
+			if (got.Mask.AlphaAt(x, y).A == 0xFF) != wantMask {
+				t.Errorf("Mask.At(%v, %v) = %v", x, y, got.Mask.AlphaAt(x, y))
+			}
+			if (got.Invert.AlphaAt(x, y).A == 0xFF) != wantInvert {
+				t.Errorf("Invert.At(%v, %v) = %v", x, y, got.Invert.AlphaAt(x, y))
+			}
+			if _, _, _, a := got.Image.At(x, y).RGBA(); (a == 0xFFFF) != wantMask {
+				t.Errorf("Image.At(%v, %v) = %v", x, y, got.Image.At(x, y))
+			}
+		}
+	}
+
+	if got.Hotspot != image.Pt(3, 5) {
+		t.Errorf("Hotspot = %v, want %v", got.Hotspot, image.Pt(3, 5))
+	}
+}
+
+func TestCursFromBytes(t *testing.T) {
+	data, mask := testCursorBits(t)
+
+	b := append(append(data, mask...), 0, 5, 0, 3)
+
+	got, err := CursFromBytes(b)
+	if err != nil {
+		t.Fatalf("CursFromBytes() error = %v", err)
+	}
+
+	checkCursorMasks(t, got)
+
+	for x := 0; x < 8; x++ {
+		want := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+		if x >= 4 {
+			want = color.NRGBA{A: 0xFF}
+		}
+		if c := got.Image.At(x, 7); c != want {
+			t.Errorf("Image.At(%v, 7) = %v, want %v", x, c, want)
+		}
+	}
+
+	if _, err := CursFromBytes(b[:66]); err == nil {
+		t.Errorf("CursFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestCrsrFromBytes(t *testing.T) {
+	data, mask := testCursorBits(t)
+
+	colors := []color.NRGBA{
+		{R: 0xFF, A: 0xFF},
+		{G: 0xFF, A: 0xFF},
+		{B: 0xFF, A: 0xFF},
+		{A: 0xFF},
+	}
+
+	const (
+		crsrMap  = 96
+		ctTable  = crsrMap + 50
+		crsrData = ctTable + 8 + 4*8
+	)
+
+	var w dataStructureWrite
+	w.writeWord(0x8001)
+	w.writeDWord(crsrMap)
+	w.writeDWord(crsrData)
+	w.writeDWord(0)
+	w.writeWord(0)
+	w.writeDWord(0)
+	w.writeData(data)
+	w.writeData(mask)
+	w.writeWord(5)
+	w.writeWord(3)
+	w.writeDWord(0)
+	w.writeDWord(0)
+
+	// 16x16 PixMap at 2 bits per pixel.
+	w.writeDWord(0)
+	w.writeWord(0x8000 | 4)
+	w.writeQDRect(image.Rect(0, 0, 16, 16))
+	w.writeWord(0)
+	w.writeWord(0)
+	w.writeDWord(0)
+	w.writeDWord(0x00480000)
+	w.writeDWord(0x00480000)
+	w.writeWord(0)
+	w.writeWord(2)
+	w.writeWord(1)
+	w.writeWord(2)
+	w.writeDWord(0)
+	w.writeDWord(ctTable)
+	w.writeDWord(0)
+
+	w.writeDWord(0)
+	w.writeWord(0)
+	w.writeWord(uint16(len(colors) - 1))
+	for i, c := range colors {
+		w.writeWord(uint16(i))
+		w.writeWord(uint16(c.R) * 0x101)
+		w.writeWord(uint16(c.G) * 0x101)
+		w.writeWord(uint16(c.B) * 0x101)
+	}
+
+	value := func(x, y int) uint8 { return uint8((x + y) % 4) }
+	w.writeData(testIconPixels(image.Pt(16, 16), 2, value))
+
+	got, err := CrsrFromBytes(w.b.Bytes())
+	if err != nil {
+		t.Fatalf("CrsrFromBytes() error = %v", err)
+	}
+
+	checkCursorMasks(t, got)
+
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 8; x++ {
+			if c := got.Image.At(x, y); c != colors[value(x, y)] {
+				t.Errorf("Image.At(%v, %v) = %v, want %v", x, y, c, colors[value(x, y)])
+			}
+		}
+	}
+
+	if _, err := CrsrFromBytes(w.b.Bytes()[:120]); err == nil {
+		t.Errorf("CrsrFromBytes() error = nil, want an error for short data")
+	}
+}
@@ -0,0 +1,194 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// PICT pictures are recognised by the version marker that follows their size and frame, with or
+// without the 512 byte header of PICT files, and are only decoded when the frame is not empty. Neither
+// cicn nor rlëD resources begin with anything distinctive enough to sniff, so they are not registered.
+//
+// MacPaint documents are recognised by the PNTG file type of their MacBinary header. Bare documents
+// begin with little more than a small version number, which too much other data shares, so they have to
+// be decoded with MacPaintDecode directly.
+func init() {
+	var (
+		raw    = strings.Repeat("?", 10)
+		header = strings.Repeat("?", pictFileHeaderSize+10)
+	)
+
+	image.RegisterFormat("pict", raw+"\x00\x11\x02\xff", PictDecode, PictDecodeConfig)
+	image.RegisterFormat("pict", header+"\x00\x11\x02\xff", PictDecode, PictDecodeConfig)
+	image.RegisterFormat("pict", raw+"\x11\x01", PictDecode, PictDecodeConfig)
+	image.RegisterFormat("pict", header+"\x11\x01", PictDecode, PictDecodeConfig)
+
+	image.RegisterFormat("pntg", strings.Repeat("?", 65)+"PNTG", MacPaintDecode, MacPaintDecodeConfig)
+}
+
+// PictDecode decodes a PICT, with or without its file header.
+func PictDecode(r io.Reader) (image.Image, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b = stripPictFileHeader(b)
+	if _, err := pictFrame(b); err != nil {
+		return nil, err
+	}
+	return PictFromBytes(b)
+}
+
+// PictDecodeConfig returns the dimensions of a PICT's frame without decoding its opcodes.
+func PictDecodeConfig(r io.Reader) (image.Config, error) {
+	var b = make([]byte, pictFileHeaderSize+2+8+4)
+	n, err := io.ReadFull(r, b)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return image.Config{}, err
+	}
+
+	frame, err := pictFrame(stripPictFileHeader(b[:n]))
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      frame.Dx(),
+		Height:     frame.Dy(),
+	}, nil
+}
+
+// pictFrame returns the frame of a PICT stripped of its file header. The version marker the format is
+// sniffed by is only two bytes long for version 1, so data that is not a PICT is also told apart by a
+// frame that does not enclose any pixels, and reported as image.ErrFormat.
+func pictFrame(b []byte) (image.Rectangle, error) {
+	if len(b) < 2+8+2 {
+		return image.Rectangle{}, image.ErrFormat
+	}
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: WordSize,
+	}
+
+	if v := parser.d.GetUint16(10); v != 0x1101 && v != 0x0011 {
+		return image.Rectangle{}, image.ErrFormat
+	}
+
+	var r = parser.readQDRect()
+	if int16(r.y1) >= int16(r.y2) || int16(r.x1) >= int16(r.x2) {
+		return image.Rectangle{}, image.ErrFormat
+	}
+
+	return r.rectangle(), nil
+}
+
+// CicnDecode decodes a cicn resource.
+func CicnDecode(r io.Reader) (image.Image, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return CicnFromBytes(b)
+}
+
+// CicnDecodeConfig returns the dimensions of a cicn resource from its PixMap.
+func CicnDecodeConfig(r io.Reader) (image.Config, error) {
+	var b = make([]byte, 50)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return image.Config{}, err
+	}
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	var px = parser.parsePixMap()
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      int(px.bounds.width),
+		Height:     int(px.bounds.height),
+	}, nil
+}
+
+// RleDecode decodes an rlëD or rlë8 resource into its sprite sheet.
+func RleDecode(r io.Reader) (image.Image, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rle, err := RleFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return rle.Image, nil
+}
+
+// RleDecodeConfig returns the dimensions of the sprite sheet an rlëD or rlë8 resource decodes to.
+func RleDecodeConfig(r io.Reader) (image.Config, error) {
+	var b = make([]byte, 16)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return image.Config{}, err
+	}
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	var (
+		width        = int(parser.readWord())
+		height       = int(parser.readWord())
+		bitsPerPixel = parser.readWord()
+		_            = parser.readWord()
+		frameCount   = parser.readWord()
+	)
+
+	if bitsPerPixel != 16 && bitsPerPixel != 8 {
+		return image.Config{}, errors.New(fmt.Sprintf("invalid color depth %v in rlë resource", bitsPerPixel))
+	}
+
+	var divisor = getRoughDivisor(frameCount)
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      width * int(divisor),
+		Height:     height * int(frameCount/divisor),
+	}, nil
+}
+
+// MacPaintDecode decodes a MacPaint document, bare or wrapped in MacBinary.
+func MacPaintDecode(r io.Reader) (image.Image, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return MacPaintFromBytes(b)
+}
+
+// MacPaintDecodeConfig returns the dimensions of a MacPaint document, which are always the same, once
+// its header, or the MacBinary header wrapping it, is found to be valid.
+func MacPaintDecodeConfig(r io.Reader) (image.Config, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	if _, err := macPaintDocument(b); err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: MacPaintPalette,
+		Width:      macPaintWidth,
+		Height:     macPaintHeight,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package gomacimage
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"testing"
+)
+
+func TestImageDecode_Pict(t *testing.T) {
+	binaryData, err := ioutil.ReadFile("test/fixtures/pict/statusBar.bin")
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile() error = %v", err)
+	}
+
+	want, err := PictFromBytes(binaryData)
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "resource", data: binaryData},
+		{name: "file", data: append(make([]byte, pictFileHeaderSize), binaryData...)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, format, err := image.DecodeConfig(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("image.DecodeConfig() error = %v", err)
+			}
+			if format != "pict" {
+				t.Errorf("image.DecodeConfig() format = %v, want pict", format)
+			}
+			if config.Width != want.Bounds().Dx() || config.Height != want.Bounds().Dy() {
+				t.Errorf("image.DecodeConfig() size = %vx%v, want %v", config.Width, config.Height, want.Bounds().Size())
+			}
+
+			got, format, err := image.Decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("image.Decode() error = %v", err)
+			}
+			if format != "pict" {
+				t.Errorf("image.Decode() format = %v, want pict", format)
+			}
+
+			_, _, errs := fuzzyCompImage(got, want)
+			for _, err := range errs {
+				t.Errorf("fuzzyCompImage() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestImageDecode_PictInvalidFrame(t *testing.T) {
+	// Data sharing the version 1 marker, but whose frame runs backwards or is empty.
+	backwards := []byte{0, 0, 0, 10, 0, 10, 0, 0, 0, 0, 0x11, 0x01, 0xFF}
+	empty := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x11, 0x01, 0xFF}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "backwards", data: backwards},
+		{name: "empty", data: empty},
+		{name: "file", data: append(bytes.Repeat([]byte{0x55}, pictFileHeaderSize), empty...)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, _, err := image.DecodeConfig(bytes.NewReader(tt.data)); err != image.ErrFormat {
+				t.Errorf("image.DecodeConfig() error = %v, want image.ErrFormat", err)
+			}
+			if _, _, err := image.Decode(bytes.NewReader(tt.data)); err != image.ErrFormat {
+				t.Errorf("image.Decode() error = %v, want image.ErrFormat", err)
+			}
+		})
+	}
+}
+
+func TestCicnDecodeConfig(t *testing.T) {
+	binaryData, err := ioutil.ReadFile("test/fixtures/cicn/10000.bin")
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile() error = %v", err)
+	}
+
+	want, err := CicnDecode(bytes.NewReader(binaryData))
+	if err != nil {
+		t.Fatalf("CicnDecode() error = %v", err)
+	}
+
+	config, err := CicnDecodeConfig(bytes.NewReader(binaryData))
+	if err != nil {
+		t.Fatalf("CicnDecodeConfig() error = %v", err)
+	}
+	if config.Width != want.Bounds().Dx() || config.Height != want.Bounds().Dy() {
+		t.Errorf("CicnDecodeConfig() size = %vx%v, want %v", config.Width, config.Height, want.Bounds().Size())
+	}
+}
+
+func TestRleDecodeConfig(t *testing.T) {
+	binaryData, err := ioutil.ReadFile("test/fixtures/rle/1006.bin")
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile() error = %v", err)
+	}
+
+	want, err := RleDecode(bytes.NewReader(binaryData))
+	if err != nil {
+		t.Fatalf("RleDecode() error = %v", err)
+	}
+
+	config, err := RleDecodeConfig(bytes.NewReader(binaryData))
+	if err != nil {
+		t.Fatalf("RleDecodeConfig() error = %v", err)
+	}
+	if config.Width != want.Bounds().Dx() || config.Height != want.Bounds().Dy() {
+		t.Errorf("RleDecodeConfig() size = %vx%v, want %v", config.Width, config.Height, want.Bounds().Size())
+	}
+}
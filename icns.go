@@ -0,0 +1,270 @@
+package gomacimage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+const icnsHeaderSize = 8
+
+// UnsupportedIcnsElementError describes an icns element holding image data in a format that cannot be
+// decoded. It is returned as an error when an icns has no other image.
+type UnsupportedIcnsElementError struct {
+	Type   string // The four character code of the element
+	Format string // The format of the element's data, such as "JPEG 2000"
+}
+
+func (e UnsupportedIcnsElementError) Error() string {
+	return fmt.Sprintf("unsupported %s data in icns element '%s'", e.Format, e.Type)
+}
+
+// icnsChannelMember describes an icns element holding RLE packed RGB channels, along with the element
+// holding its 8-bit mask.
+type icnsChannelMember struct {
+	size int
+	mask string
+}
+
+var icnsChannelMembers = map[string]icnsChannelMember{
+	"is32": {size: 16, mask: "s8mk"},
+	"il32": {size: 32, mask: "l8mk"},
+	"ih32": {size: 48, mask: "h8mk"},
+	"it32": {size: 128, mask: "t8mk"},
+}
+
+// icnsEncodedMembers lists the elements holding PNG or JPEG 2000 data.
+var icnsEncodedMembers = map[string]bool{
+	"icp4": true, "icp5": true, "icp6": true,
+	"ic07": true, "ic08": true, "ic09": true, "ic10": true,
+	"ic11": true, "ic12": true, "ic13": true, "ic14": true,
+}
+
+// The quality of each kind of element, used to pick one image for each size.
+const (
+	icnsQuality1Bit = iota
+	icnsQuality4Bit
+	icnsQuality8Bit
+	icnsQualityChannels
+	icnsQualityEncoded
+)
+
+// IcnsFromBytes decodes an icns file or resource into its images, keyed by their width. When an icns
+// holds more than one image of the same width, the one with the most colors is kept: PNG elements,
+// then RGB elements with their 8-bit masks, then the classic icon family members.
+//
+// Elements that cannot be decoded, such as those holding JPEG 2000 data, are skipped and listed in
+// skipped. The first of them is only returned as the error when no image could be decoded.
+func IcnsFromBytes(b []byte) (images map[int]image.Image, skipped []UnsupportedIcnsElementError, err error) {
+	elements, err := icnsElements(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		qualities = map[int]int{}
+		classic   = map[string][]byte{}
+	)
+
+	images = map[int]image.Image{}
+
+	add := func(img image.Image, quality int) {
+		var width = img.Bounds().Dx()
+		if current, ok := qualities[width]; ok && current > quality {
+			return
+		}
+		images[width] = img
+		qualities[width] = quality
+	}
+
+	for _, element := range elements {
+		switch {
+		case icnsEncodedMembers[element.resType]:
+			img, err := icnsEncodedImage(element.resType, element.data)
+			if unsupported, ok := err.(UnsupportedIcnsElementError); ok {
+				skipped = append(skipped, unsupported)
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			add(img, icnsQualityEncoded)
+
+		case icnsChannelMembers[element.resType].size != 0:
+			var member = icnsChannelMembers[element.resType]
+			img, err := icnsChannelImage(element.resType, element.data, member.size, findIcnsElement(elements, member.mask))
+			if err != nil {
+				return nil, nil, err
+			}
+			add(img, icnsQualityChannels)
+
+		case iconFamilyMembers[element.resType].depth != 0:
+			classic[element.resType] = element.data
+		}
+	}
+
+	family, err := IconFamilyFromResources(classic)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for resType, img := range family.Members {
+		var member = iconFamilyMembers[resType]
+		if member.size.X != member.size.Y {
+			continue
+		}
+
+		switch member.depth {
+		case 1:
+			add(img, icnsQuality1Bit)
+		case 4:
+			add(img, icnsQuality4Bit)
+		case 8:
+			add(img, icnsQuality8Bit)
+		}
+	}
+
+	if len(images) == 0 && len(skipped) > 0 {
+		return nil, skipped, skipped[0]
+	}
+
+	return images, skipped, nil
+}
+
+type icnsElement struct {
+	resType string
+	data    []byte
+}
+
+// icnsElements walks the element table of an icns.
+func icnsElements(b []byte) ([]icnsElement, error) {
+	if len(b) < icnsHeaderSize || string(b[:4]) != "icns" {
+		return nil, errors.New("invalid icns header")
+	}
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 4,
+	}
+
+	var length = int(parser.readDWord())
+	if length > len(b) || length < icnsHeaderSize {
+		return nil, errors.New(fmt.Sprintf("icns length %v does not match its %v bytes", length, len(b)))
+	}
+
+	var elements []icnsElement
+	for parser.pos+icnsHeaderSize <= length {
+		var (
+			resType = string(parser.readDataUint8(4))
+			size    = int(parser.readDWord())
+		)
+
+		if size < icnsHeaderSize || parser.pos-icnsHeaderSize+size > length {
+			return nil, errors.New(fmt.Sprintf("invalid length %v of icns element '%s'", size, resType))
+		}
+
+		elements = append(elements, icnsElement{
+			resType: resType,
+			data:    parser.readDataUint8(size - icnsHeaderSize),
+		})
+	}
+
+	return elements, nil
+}
+
+func findIcnsElement(elements []icnsElement, resType string) []byte {
+	for _, element := range elements {
+		if element.resType == resType {
+			return element.data
+		}
+	}
+	return nil
+}
+
+// icnsEncodedImage decodes an element holding a PNG, and reports JPEG 2000 data as unsupported.
+func icnsEncodedImage(resType string, data []byte) (image.Image, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return png.Decode(bytes.NewReader(data))
+	case bytes.HasPrefix(data, []byte("\x00\x00\x00\x0cjP  ")), bytes.HasPrefix(data, []byte{0xFF, 0x4F, 0xFF, 0x51}):
+		return nil, UnsupportedIcnsElementError{Type: resType, Format: "JPEG 2000"}
+	}
+	return nil, UnsupportedIcnsElementError{Type: resType, Format: "unknown"}
+}
+
+// icnsChannelImage decodes an element holding the red, green and blue channels of a size by size
+// image one after the other, each packed with the icns RLE scheme. The alpha comes from maskData, an
+// 8-bit mask of the same size, or is opaque without it.
+func icnsChannelImage(resType string, data []byte, size int, maskData []byte) (*image.NRGBA, error) {
+	// The largest element begins with four unused bytes.
+	if resType == "it32" && len(data) >= 4 {
+		data = data[4:]
+	}
+
+	var pixels = size * size
+
+	channels, err := icnsUnpackChannels(data, 3*pixels)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("icns element '%s': %v", resType, err))
+	}
+
+	if maskData != nil && len(maskData) < pixels {
+		return nil, errors.New(fmt.Sprintf("icns mask of element '%s' is %v bytes, expected %v", resType, len(maskData), pixels))
+	}
+
+	var img = image.NewNRGBA(image.Rect(0, 0, size, size))
+	for i := 0; i < pixels; i++ {
+		var c = color.NRGBA{
+			R: channels[i],
+			G: channels[pixels+i],
+			B: channels[2*pixels+i],
+			A: 0xFF,
+		}
+		if maskData != nil {
+			c.A = maskData[i]
+		}
+		img.SetNRGBA(i%size, i/size, c)
+	}
+
+	return img, nil
+}
+
+// icnsUnpackChannels expands icns RLE data into count bytes. A control byte below 0x80 is followed by
+// that many plus one literal bytes, while a higher one repeats the following byte its value minus 125
+// times.
+func icnsUnpackChannels(data []byte, count int) ([]byte, error) {
+	var out = make([]byte, 0, count)
+
+	for pos := 0; len(out) < count; {
+		if pos >= len(data) {
+			return nil, errors.New(fmt.Sprintf("RLE data ended after %v of %v bytes", len(out), count))
+		}
+
+		var control = int(data[pos])
+		pos++
+
+		if control < 0x80 {
+			var n = control + 1
+			if pos+n > len(data) || len(out)+n > count {
+				return nil, errors.New("RLE literal run overflows its channel")
+			}
+			out = append(out, data[pos:pos+n]...)
+			pos += n
+			continue
+		}
+
+		var n = control - 125
+		if pos >= len(data) || len(out)+n > count {
+			return nil, errors.New("RLE repeated run overflows its channel")
+		}
+		for i := 0; i < n; i++ {
+			out = append(out, data[pos])
+		}
+		pos++
+	}
+
+	return out, nil
+}
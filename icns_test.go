@@ -0,0 +1,157 @@
+package gomacimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// testIcns wraps elements, given as alternating types and data, into an icns.
+func testIcns(elements ...interface{}) []byte {
+	var w dataStructureWrite
+	w.writeData([]byte("icns"))
+	w.writeDWord(0)
+
+	for i := 0; i < len(elements); i += 2 {
+		data := elements[i+1].([]byte)
+		w.writeData([]byte(elements[i].(string)))
+		w.writeDWord(uint32(len(data) + 8))
+		w.writeData(data)
+	}
+
+	b := w.b.Bytes()
+	b[4], b[5], b[6], b[7] = uint8(len(b)>>24), uint8(len(b)>>16), uint8(len(b)>>8), uint8(len(b))
+	return b
+}
+
+func TestIcnsUnpackChannels(t *testing.T) {
+	got, err := icnsUnpackChannels([]byte{0x02, 1, 2, 3, 0x80, 9, 0xFF, 7}, 136)
+	if err != nil {
+		t.Fatalf("icnsUnpackChannels() error = %v", err)
+	}
+
+	want := append([]byte{1, 2, 3, 9, 9, 9}, bytes.Repeat([]byte{7}, 130)...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("icnsUnpackChannels() = %v, want %v", got, want)
+	}
+
+	if _, err := icnsUnpackChannels([]byte{0x02, 1, 2}, 3); err == nil {
+		t.Errorf("icnsUnpackChannels() error = nil, want an error for a truncated literal")
+	}
+	if _, err := icnsUnpackChannels([]byte{0x85, 1}, 4); err == nil {
+		t.Errorf("icnsUnpackChannels() error = nil, want an error for a run past the end")
+	}
+}
+
+func TestIcnsFromBytes(t *testing.T) {
+	// A 16x16 is32 where every channel is a pair of repeated runs of 130 and 126 bytes.
+	channel := func(v byte) []byte {
+		return []byte{0xFF, v, 0xFB, v}
+	}
+	is32 := append(append(channel(0x10), channel(0x20)...), channel(0x30)...)
+	s8mk := bytes.Repeat([]byte{0x80}, 256)
+
+	large := image.Pt(32, 32)
+	icn := append(testIconPixels(large, 1, func(x, y int) uint8 { return 1 }), testIconPixels(large, 1, func(x, y int) uint8 { return uint8(x % 2) })...)
+	icl8 := testIconPixels(large, 8, func(x, y int) uint8 { return 5 })
+
+	pngImage := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	pngImage.SetNRGBA(3, 4, color.NRGBA{R: 0xAB, A: 0x7F})
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, pngImage); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	b := testIcns(
+		"TOC ", []byte{0, 0, 0, 0},
+		"ICN#", icn,
+		"icl8", icl8,
+		"is32", is32,
+		"s8mk", s8mk,
+		"icp6", pngData.Bytes(),
+	)
+
+	got, skipped, err := IcnsFromBytes(b)
+	if err != nil {
+		t.Fatalf("IcnsFromBytes() error = %v", err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("IcnsFromBytes() skipped = %v, want none", skipped)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("IcnsFromBytes() returned sizes %v, want 16, 32 and 64", got)
+	}
+
+	if c := got[16].At(7, 9); c != (color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0x80}) {
+		t.Errorf("IcnsFromBytes()[16].At(7, 9) = %v", c)
+	}
+
+	if _, _, _, a := got[32].At(0, 0).RGBA(); a != 0 {
+		t.Errorf("IcnsFromBytes()[32] is not masked by the ICN#")
+	}
+	if c := got[32].At(1, 0); color.NRGBAModel.Convert(c) != color.NRGBAModel.Convert(SystemPalette8[5]) {
+		t.Errorf("IcnsFromBytes()[32].At(1, 0) = %v, want the icl8 color %v", c, SystemPalette8[5])
+	}
+
+	if c := color.NRGBAModel.Convert(got[64].At(3, 4)); c != (color.NRGBA{R: 0xAB, A: 0x7F}) {
+		t.Errorf("IcnsFromBytes()[64].At(3, 4) = %v", c)
+	}
+}
+
+func TestIcnsFromBytes_JPEG2000(t *testing.T) {
+	is32 := []byte{0xFF, 1, 0xFB, 1, 0xFF, 2, 0xFB, 2, 0xFF, 3, 0xFB, 3}
+	jp2 := []byte("\x00\x00\x00\x0cjP  \r\n\x87\n")
+
+	got, skipped, err := IcnsFromBytes(testIcns("is32", is32, "ic08", jp2))
+	if err != nil {
+		t.Fatalf("IcnsFromBytes() error = %v, want nil while the is32 decodes", err)
+	}
+
+	want := []UnsupportedIcnsElementError{{Type: "ic08", Format: "JPEG 2000"}}
+	if len(skipped) != 1 || skipped[0] != want[0] {
+		t.Errorf("IcnsFromBytes() skipped = %#v, want %#v", skipped, want)
+	}
+
+	if got[16] == nil {
+		t.Errorf("IcnsFromBytes() did not return the is32 image")
+	}
+
+	// With nothing else to decode, the skipped element is the error.
+	got, skipped, err = IcnsFromBytes(testIcns("ic08", jp2))
+
+	unsupported, ok := err.(UnsupportedIcnsElementError)
+	if !ok {
+		t.Fatalf("IcnsFromBytes() error = %v, want an UnsupportedIcnsElementError", err)
+	}
+	if unsupported != want[0] {
+		t.Errorf("IcnsFromBytes() error = %#v, want %#v", unsupported, want[0])
+	}
+	if got != nil || len(skipped) != 1 {
+		t.Errorf("IcnsFromBytes() = %v, %v, want no images and one skipped element", got, skipped)
+	}
+}
+
+func TestIcnsFromBytes_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "magic", b: []byte("icnx\x00\x00\x00\x08")},
+		{name: "length", b: []byte("icns\x00\x00\x01\x00")},
+		{name: "element length", b: testIcns("is32", []byte{1, 2, 3, 4})[:14]},
+		{name: "channels", b: testIcns("is32", []byte{0xFF, 1})},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, _, err := IcnsFromBytes(tt.b); err == nil {
+				t.Errorf("IcnsFromBytes() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package gomacimage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sort"
+)
+
+// IcnsEncodeOptions are the encoding parameters for IcnsEncode.
+type IcnsEncodeOptions struct {
+	// Legacy also writes the RGB and 8-bit mask elements read by older tools: is32 and s8mk at 16x16,
+	// and il32 and l8mk at 32x32. Missing sizes are scaled down from the smallest larger image, and left
+	// out when there is none.
+	Legacy bool
+}
+
+// icnsPNGTypes maps image sizes to the icns elements holding them as PNG.
+var icnsPNGTypes = map[int]string{
+	128:  "ic07",
+	256:  "ic08",
+	512:  "ic09",
+	1024: "ic10",
+}
+
+// IcnsEncode writes images as an icns file. Each image must be square, and 128, 256, 512 or 1024
+// pixels wide, to be stored as a PNG element, or 16 or 32 pixels wide when legacy elements are written.
+func IcnsEncode(w io.Writer, images []image.Image, opts *IcnsEncodeOptions) error {
+	if len(images) == 0 {
+		return errors.New("cannot encode an icns without images")
+	}
+
+	var (
+		legacy  = opts != nil && opts.Legacy
+		bySize  = map[int]image.Image{}
+		sizes   []int
+		out     dataStructureWrite
+		element = func(resType string, data []byte) {
+			out.writeData([]byte(resType))
+			out.writeDWord(uint32(len(data) + icnsHeaderSize))
+			out.writeData(data)
+		}
+	)
+
+	for _, img := range images {
+		var size = img.Bounds().Size()
+		if size.X != size.Y {
+			return errors.New(fmt.Sprintf("icns images must be square, not %v", size))
+		}
+
+		_, isPNG := icnsPNGTypes[size.X]
+		if !isPNG && !(legacy && (size.X == 16 || size.X == 32)) {
+			return errors.New(fmt.Sprintf("unsupported icns image size: %v", size))
+		}
+
+		if _, ok := bySize[size.X]; ok {
+			return errors.New(fmt.Sprintf("more than one icns image of size %v", size))
+		}
+
+		bySize[size.X] = img
+		sizes = append(sizes, size.X)
+	}
+
+	sort.Ints(sizes)
+
+	out.writeData([]byte("icns"))
+	out.writeDWord(0) // filled in once complete
+
+	if legacy {
+		for _, member := range []struct{ resType, mask string }{{"is32", "s8mk"}, {"il32", "l8mk"}} {
+			var size = icnsChannelMembers[member.resType].size
+
+			img, ok := bySize[size]
+			for _, s := range sizes {
+				if !ok && s > size {
+					img, ok = scaleDown(bySize[s], size), true
+				}
+			}
+			if !ok {
+				continue
+			}
+
+			channels, mask := icnsChannels(img)
+			element(member.resType, channels)
+			element(member.mask, mask)
+		}
+	}
+
+	for _, size := range sizes {
+		resType, ok := icnsPNGTypes[size]
+		if !ok {
+			continue
+		}
+
+		var data bytes.Buffer
+		if err := png.Encode(&data, bySize[size]); err != nil {
+			return err
+		}
+		element(resType, data.Bytes())
+	}
+
+	var b = out.b.Bytes()
+	b[4], b[5], b[6], b[7] = uint8(len(b)>>24), uint8(len(b)>>16), uint8(len(b)>>8), uint8(len(b))
+
+	_, err := w.Write(b)
+	return err
+}
+
+// icnsChannels splits img into its red, green and blue channels, each packed with the icns RLE scheme,
+// and its 8-bit alpha mask.
+func icnsChannels(img image.Image) ([]byte, []byte) {
+	var (
+		bounds = img.Bounds()
+		pixels = bounds.Dx() * bounds.Dy()
+		planes = make([]byte, 3*pixels)
+		mask   = make([]byte, pixels)
+	)
+
+	for i := 0; i < pixels; i++ {
+		c := color.NRGBAModel.Convert(img.At(bounds.Min.X+i%bounds.Dx(), bounds.Min.Y+i/bounds.Dx())).(color.NRGBA)
+		planes[i], planes[pixels+i], planes[2*pixels+i] = c.R, c.G, c.B
+		mask[i] = c.A
+	}
+
+	var packed []byte
+	for i := 0; i < 3; i++ {
+		packed = append(packed, icnsPackChannel(planes[i*pixels:(i+1)*pixels])...)
+	}
+	return packed, mask
+}
+
+// icnsPackChannel packs a channel into the runs icnsUnpackChannels expands. Runs of three to 130 equal
+// bytes are repeated, and anything else is copied in literal runs of up to 128 bytes.
+func icnsPackChannel(data []byte) []byte {
+	var result []byte
+
+	for i := 0; i < len(data); {
+		var run = 1
+		for i+run < len(data) && run < 130 && data[i+run] == data[i] {
+			run++
+		}
+
+		if run >= 3 {
+			result = append(result, uint8(run+125), data[i])
+			i += run
+			continue
+		}
+
+		// Gather literal bytes until the next worthwhile run begins.
+		var literal = 0
+		for i+literal < len(data) && literal < 128 {
+			if i+literal+2 < len(data) && data[i+literal] == data[i+literal+1] && data[i+literal] == data[i+literal+2] {
+				break
+			}
+			literal++
+		}
+
+		result = append(result, uint8(literal-1))
+		result = append(result, data[i:i+literal]...)
+		i += literal
+	}
+
+	return result
+}
+
+// scaleDown shrinks img to a size by size image, averaging the pixels each destination pixel covers.
+func scaleDown(img image.Image, size int) *image.NRGBA {
+	var (
+		bounds = img.Bounds()
+		dst    = image.NewNRGBA(image.Rect(0, 0, size, size))
+	)
+
+	for y := 0; y < size; y++ {
+		y0, y1 := bounds.Min.Y+y*bounds.Dy()/size, bounds.Min.Y+(y+1)*bounds.Dy()/size
+		for x := 0; x < size; x++ {
+			x0, x1 := bounds.Min.X+x*bounds.Dx()/size, bounds.Min.X+(x+1)*bounds.Dx()/size
+
+			// Average premultiplied colors so transparent pixels do not darken the edges.
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			if n == 0 {
+				continue
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,123 @@
+package gomacimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestIcnsPackChannel(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "run", data: bytes.Repeat([]byte{9}, 300)},
+		{name: "literal", data: func() []byte {
+			d := make([]byte, 300)
+			for i := range d {
+				d[i] = uint8(i)
+			}
+			return d
+		}()},
+		{name: "mixed", data: []byte{1, 1, 2, 2, 2, 3, 4, 4, 4, 4, 5}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := icnsUnpackChannels(icnsPackChannel(tt.data), len(tt.data))
+			if err != nil {
+				t.Fatalf("icnsUnpackChannels() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("icnsUnpackChannels(icnsPackChannel()) = %v, want %v", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestIcnsEncode(t *testing.T) {
+	icon := func(size int) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.NRGBA{R: 0x20, G: 0x40, B: 0x60, A: 0xFF}), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(0, 0, size/2, size), image.Transparent, image.Point{}, draw.Src)
+		img.SetNRGBA(size-1, 0, color.NRGBA{R: 0xFF, A: 0x80})
+		return img
+	}
+
+	tests := []struct {
+		name   string
+		images []image.Image
+		opts   *IcnsEncodeOptions
+		sizes  []int
+	}{
+		{name: "png", images: []image.Image{icon(128), icon(256)}, sizes: []int{128, 256}},
+		{name: "legacy scaled", images: []image.Image{icon(256), icon(128)}, opts: &IcnsEncodeOptions{Legacy: true}, sizes: []int{16, 32, 128, 256}},
+		{name: "legacy given", images: []image.Image{icon(16), icon(32), icon(128)}, opts: &IcnsEncodeOptions{Legacy: true}, sizes: []int{16, 32, 128}},
+		{name: "legacy small", images: []image.Image{icon(16)}, opts: &IcnsEncodeOptions{Legacy: true}, sizes: []int{16}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			if err := IcnsEncode(&b, tt.images, tt.opts); err != nil {
+				t.Fatalf("IcnsEncode() error = %v", err)
+			}
+
+			got, _, err := IcnsFromBytes(b.Bytes())
+			if err != nil {
+				t.Fatalf("IcnsFromBytes() error = %v", err)
+			}
+
+			if len(got) != len(tt.sizes) {
+				t.Errorf("IcnsFromBytes() returned %v sizes, want %v", len(got), tt.sizes)
+			}
+
+			for _, size := range tt.sizes {
+				img, ok := got[size]
+				if !ok {
+					t.Errorf("IcnsFromBytes() is missing size %v", size)
+					continue
+				}
+
+				// The right half is opaque, apart from the top right corner, and the left half transparent.
+				if c := color.NRGBAModel.Convert(img.At(size-2, size-1)); c != (color.NRGBA{R: 0x20, G: 0x40, B: 0x60, A: 0xFF}) {
+					t.Errorf("size %v At(%v, %v) = %v", size, size-2, size-1, c)
+				}
+				if _, _, _, a := img.At(0, size/2).RGBA(); a != 0 {
+					t.Errorf("size %v At(0, %v) is not transparent", size, size/2)
+				}
+			}
+		})
+	}
+}
+
+func TestIcnsEncode_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		images []image.Image
+		opts   *IcnsEncodeOptions
+	}{
+		{name: "empty"},
+		{name: "not square", images: []image.Image{image.NewNRGBA(image.Rect(0, 0, 128, 64))}},
+		{name: "size", images: []image.Image{image.NewNRGBA(image.Rect(0, 0, 100, 100))}},
+		{name: "legacy size without legacy", images: []image.Image{image.NewNRGBA(image.Rect(0, 0, 32, 32))}},
+		{name: "duplicate", images: []image.Image{image.NewNRGBA(image.Rect(0, 0, 128, 128)), image.NewNRGBA(image.Rect(0, 0, 128, 128))}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			if err := IcnsEncode(&b, tt.images, tt.opts); err == nil {
+				t.Errorf("IcnsEncode() error = nil, want an error")
+			}
+		})
+	}
+}
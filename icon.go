@@ -0,0 +1,79 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	iconSize      = 32
+	iconRowBytes  = iconSize / 8
+	iconDataSize  = iconRowBytes * iconSize
+	smallIconSize = 16
+	sicnRowBytes  = smallIconSize / 8
+	sicnDataSize  = sicnRowBytes * smallIconSize
+)
+
+// IconFromBytes decodes an ICON resource, a 32x32 black and white icon without a mask.
+func IconFromBytes(b []byte) (image.Image, error) {
+	if len(b) < iconDataSize {
+		return nil, errors.New(fmt.Sprintf("ICON resource is %v bytes, expected %v", len(b), iconDataSize))
+	}
+
+	return bitMapImage(b[:iconDataSize], nil, iconRowBytes, iconSize, iconSize), nil
+}
+
+// IconListFromBytes decodes an ICN# resource, a 32x32 black and white icon followed by its mask. The
+// returned icon is transparent wherever the mask is clear, and the mask is returned on its own as well.
+func IconListFromBytes(b []byte) (icon image.Image, mask *image.Alpha, err error) {
+	return iconListFromBytes("ICN#", b)
+}
+
+// SicnFromBytes decodes a SICN resource, a list of 16x16 black and white icons without masks.
+func SicnFromBytes(b []byte) ([]image.Image, error) {
+	if len(b)%sicnDataSize != 0 {
+		return nil, errors.New(fmt.Sprintf("SICN resource length %v is not a multiple of %v", len(b), sicnDataSize))
+	}
+
+	var icons = make([]image.Image, 0, len(b)/sicnDataSize)
+	for i := 0; i < len(b); i += sicnDataSize {
+		icons = append(icons, bitMapImage(b[i:i+sicnDataSize], nil, sicnRowBytes, smallIconSize, smallIconSize))
+	}
+	return icons, nil
+}
+
+// bitMapImage draws 1-bit image data in black and white. Without mask data every pixel is opaque,
+// otherwise pixels outside of the mask are transparent.
+func bitMapImage(data []uint8, maskData []uint8, rowBytes uint16, width int, height int) *image.NRGBA {
+	var img = image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var c = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+			if readBit(data, rowBytes, x, y) == 1 {
+				c = color.NRGBA{A: 0xFF}
+			}
+			if maskData != nil && readBit(maskData, rowBytes, x, y) == 0 {
+				c = color.NRGBA{}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+// bitMapMask converts 1-bit mask data into an alpha mask.
+func bitMapMask(data []uint8, rowBytes uint16, width int, height int) *image.Alpha {
+	var mask = image.NewAlpha(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mask.SetAlpha(x, y, color.Alpha{A: readBit(data, rowBytes, x, y) * 0xFF})
+		}
+	}
+
+	return mask
+}
@@ -0,0 +1,129 @@
+package gomacimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// testIconData builds 1-bit icon data of the given size with the pixels for which set returns true.
+func testIconData(size int, set func(x, y int) bool) []byte {
+	var b = make([]byte, size/8*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if set(x, y) {
+				b[y*size/8+x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+	}
+	return b
+}
+
+func TestIconFromBytes(t *testing.T) {
+	diagonal := func(x, y int) bool { return x == y }
+
+	got, err := IconFromBytes(testIconData(32, diagonal))
+	if err != nil {
+		t.Fatalf("IconFromBytes() error = %v", err)
+	}
+
+	if got.Bounds() != image.Rect(0, 0, 32, 32) {
+		t.Fatalf("IconFromBytes() bounds = %v, want %v", got.Bounds(), image.Rect(0, 0, 32, 32))
+	}
+
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			want := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+			if diagonal(x, y) {
+				want = color.NRGBA{A: 0xFF}
+			}
+			if c := got.At(x, y); c != want {
+				t.Errorf("IconFromBytes().At(%v, %v) = %v, want %v", x, y, c, want)
+			}
+		}
+	}
+
+	if _, err := IconFromBytes(make([]byte, 100)); err == nil {
+		t.Errorf("IconFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestIconListFromBytes(t *testing.T) {
+	iconBits := func(x, y int) bool { return (x+y)%3 == 0 }
+	maskBits := func(x, y int) bool { return x >= 4 && x < 28 && y >= 2 }
+
+	b := append(testIconData(32, iconBits), testIconData(32, maskBits)...)
+
+	icon, mask, err := IconListFromBytes(b)
+	if err != nil {
+		t.Fatalf("IconListFromBytes() error = %v", err)
+	}
+
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			var want color.NRGBA
+			switch {
+			case !maskBits(x, y):
+			case iconBits(x, y):
+				want = color.NRGBA{A: 0xFF}
+			default:
+				want = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+			}
+			if c := icon.At(x, y); c != want {
+				t.Errorf("IconListFromBytes() icon At(%v, %v) = %v, want %v", x, y, c, want)
+			}
+
+			wantMask := color.Alpha{}
+			if maskBits(x, y) {
+				wantMask.A = 0xFF
+			}
+			if c := mask.AlphaAt(x, y); c != wantMask {
+				t.Errorf("IconListFromBytes() mask At(%v, %v) = %v, want %v", x, y, c, wantMask)
+			}
+		}
+	}
+
+	if _, _, err := IconListFromBytes(b[:200]); err == nil {
+		t.Errorf("IconListFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestSicnFromBytes(t *testing.T) {
+	patterns := []func(x, y int) bool{
+		func(x, y int) bool { return x == 0 },
+		func(x, y int) bool { return y == 15 },
+		func(x, y int) bool { return x == y },
+	}
+
+	var b []byte
+	for _, p := range patterns {
+		b = append(b, testIconData(16, p)...)
+	}
+
+	got, err := SicnFromBytes(b)
+	if err != nil {
+		t.Fatalf("SicnFromBytes() error = %v", err)
+	}
+	if len(got) != len(patterns) {
+		t.Fatalf("SicnFromBytes() returned %v icons, want %v", len(got), len(patterns))
+	}
+
+	for i, icon := range got {
+		if icon.Bounds() != image.Rect(0, 0, 16, 16) {
+			t.Errorf("SicnFromBytes()[%v] bounds = %v, want %v", i, icon.Bounds(), image.Rect(0, 0, 16, 16))
+		}
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				_, _, _, a := icon.At(x, y).RGBA()
+				r, _, _, _ := icon.At(x, y).RGBA()
+				if a != 0xFFFF || (r == 0) != patterns[i](x, y) {
+					t.Errorf("SicnFromBytes()[%v].At(%v, %v) = %v", i, x, y, icon.At(x, y))
+				}
+			}
+		}
+	}
+
+	if _, err := SicnFromBytes(make([]byte, 33)); err == nil {
+		t.Errorf("SicnFromBytes() error = nil, want an error for a partial icon")
+	}
+}
@@ -0,0 +1,213 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+)
+
+// iconFamilyMember describes the fixed layout of one resource type of a Finder icon family.
+type iconFamilyMember struct {
+	size  image.Point
+	depth int
+	// list is the black and white icon list holding the mask of the member.
+	list string
+}
+
+func (m iconFamilyMember) rowBytes() int {
+	return m.size.X * m.depth / 8
+}
+
+func (m iconFamilyMember) dataSize() int {
+	return m.rowBytes() * m.size.Y
+}
+
+var iconFamilyMembers = map[string]iconFamilyMember{
+	"ICN#": {size: image.Pt(32, 32), depth: 1, list: "ICN#"},
+	"icl4": {size: image.Pt(32, 32), depth: 4, list: "ICN#"},
+	"icl8": {size: image.Pt(32, 32), depth: 8, list: "ICN#"},
+	"ics#": {size: image.Pt(16, 16), depth: 1, list: "ics#"},
+	"ics4": {size: image.Pt(16, 16), depth: 4, list: "ics#"},
+	"ics8": {size: image.Pt(16, 16), depth: 8, list: "ics#"},
+	"icm#": {size: image.Pt(16, 12), depth: 1, list: "icm#"},
+	"icm4": {size: image.Pt(16, 12), depth: 4, list: "icm#"},
+	"icm8": {size: image.Pt(16, 12), depth: 8, list: "icm#"},
+	"ich#": {size: image.Pt(48, 48), depth: 1, list: "ich#"},
+	"ich4": {size: image.Pt(48, 48), depth: 4, list: "ich#"},
+	"ich8": {size: image.Pt(48, 48), depth: 8, list: "ich#"},
+}
+
+// Icl4FromBytes decodes an icl4 resource, a 32x32 icon using the 16 color system palette.
+func Icl4FromBytes(b []byte) (image.Image, error) {
+	return iconMemberFromBytes("icl4", b, nil)
+}
+
+// Icl8FromBytes decodes an icl8 resource, a 32x32 icon using the 256 color system palette.
+func Icl8FromBytes(b []byte) (image.Image, error) {
+	return iconMemberFromBytes("icl8", b, nil)
+}
+
+// Ics4FromBytes decodes an ics4 resource, a 16x16 icon using the 16 color system palette.
+func Ics4FromBytes(b []byte) (image.Image, error) {
+	return iconMemberFromBytes("ics4", b, nil)
+}
+
+// Ics8FromBytes decodes an ics8 resource, a 16x16 icon using the 256 color system palette.
+func Ics8FromBytes(b []byte) (image.Image, error) {
+	return iconMemberFromBytes("ics8", b, nil)
+}
+
+// Icm4FromBytes decodes an icm4 resource, a 16x12 icon using the 16 color system palette.
+func Icm4FromBytes(b []byte) (image.Image, error) {
+	return iconMemberFromBytes("icm4", b, nil)
+}
+
+// Icm8FromBytes decodes an icm8 resource, a 16x12 icon using the 256 color system palette.
+func Icm8FromBytes(b []byte) (image.Image, error) {
+	return iconMemberFromBytes("icm8", b, nil)
+}
+
+// IcsListFromBytes decodes an ics# resource, a 16x16 black and white icon followed by its mask, in the
+// same way as IconListFromBytes.
+func IcsListFromBytes(b []byte) (icon image.Image, mask *image.Alpha, err error) {
+	return iconListFromBytes("ics#", b)
+}
+
+// IcmListFromBytes decodes an icm# resource, a 16x12 black and white icon followed by its mask, in the
+// same way as IconListFromBytes.
+func IcmListFromBytes(b []byte) (icon image.Image, mask *image.Alpha, err error) {
+	return iconListFromBytes("icm#", b)
+}
+
+func iconListFromBytes(resType string, b []byte) (image.Image, *image.Alpha, error) {
+	var member = iconFamilyMembers[resType]
+
+	maskData, err := iconListMask(resType, b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	icon, err := iconMemberFromBytes(resType, b, maskData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return icon, bitMapMask(maskData, uint16(member.rowBytes()), member.size.X, member.size.Y), nil
+}
+
+// iconListMask returns the mask half of a black and white icon list.
+func iconListMask(resType string, b []byte) ([]uint8, error) {
+	var member = iconFamilyMembers[resType]
+	var size = member.dataSize()
+
+	if len(b) < 2*size {
+		return nil, errors.New(fmt.Sprintf("%v resource is %v bytes, expected %v", resType, len(b), 2*size))
+	}
+	return b[size : 2*size], nil
+}
+
+// iconMemberFromBytes decodes the pixels of an icon family member. Pixels outside of maskData are
+// transparent, and every pixel is opaque without it.
+func iconMemberFromBytes(resType string, b []byte, maskData []uint8) (*image.NRGBA, error) {
+	var member, ok = iconFamilyMembers[resType]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unknown icon family member: %v", resType))
+	}
+
+	if len(b) < member.dataSize() {
+		return nil, errors.New(fmt.Sprintf("%v resource is %v bytes, expected %v", resType, len(b), member.dataSize()))
+	}
+
+	var rowBytes = uint16(member.rowBytes())
+
+	if member.depth == 1 {
+		return bitMapImage(b, maskData, rowBytes, member.size.X, member.size.Y), nil
+	}
+
+	var palette = SystemPalette8
+	if member.depth == 4 {
+		palette = SystemPalette4
+	}
+
+	var img = image.NewNRGBA(image.Rectangle{Max: member.size})
+	for y := 0; y < member.size.Y; y++ {
+		for x := 0; x < member.size.X; x++ {
+			if maskData != nil && readBit(maskData, uint16(member.size.X/8), x, y) == 0 {
+				continue
+			}
+
+			idx := uint32(y)*uint32(rowBytes)*8/uint32(member.depth) + uint32(x)
+			value, err := readIndexedPixel(b, idx, uint16(member.depth))
+			if err != nil {
+				return nil, err
+			}
+
+			img.Set(x, y, palette[value])
+		}
+	}
+
+	return img, nil
+}
+
+// IconFamily holds the members of a Finder icon family sharing a resource ID.
+type IconFamily struct {
+	// Members maps resource types, such as "icl8" or "ics#", to their images. Each image is masked by
+	// the matching ICN#, ics# or icm# when the family has one.
+	Members map[string]image.Image
+}
+
+// IconFamilyFromResources decodes the members of an icon family from the data of each resource, keyed
+// by resource type. Resource types that are not part of an icon family are ignored.
+func IconFamilyFromResources(resources map[string][]byte) (*IconFamily, error) {
+	var family = &IconFamily{Members: map[string]image.Image{}}
+
+	for resType, b := range resources {
+		member, ok := iconFamilyMembers[resType]
+		if !ok {
+			continue
+		}
+
+		var maskData []uint8
+		if list, ok := resources[member.list]; ok {
+			var err error
+			if maskData, err = iconListMask(member.list, list); err != nil {
+				return nil, err
+			}
+		}
+
+		img, err := iconMemberFromBytes(resType, b, maskData)
+		if err != nil {
+			return nil, err
+		}
+		family.Members[resType] = img
+	}
+
+	return family, nil
+}
+
+// Best returns the fullest member with the most colors among those of the given width, or nil when
+// the family has none of that width.
+func (f *IconFamily) Best(width int) image.Image {
+	var types []string
+	for resType := range f.Members {
+		if iconFamilyMembers[resType].size.X == width {
+			types = append(types, resType)
+		}
+	}
+
+	if len(types) == 0 {
+		return nil
+	}
+
+	// Prefer the taller member, and the deepest one between those of the same height.
+	sort.Slice(types, func(i, j int) bool {
+		a, b := iconFamilyMembers[types[i]], iconFamilyMembers[types[j]]
+		if a.size.Y != b.size.Y {
+			return a.size.Y > b.size.Y
+		}
+		return a.depth > b.depth
+	})
+
+	return f.Members[types[0]]
+}
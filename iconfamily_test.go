@@ -0,0 +1,170 @@
+package gomacimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// testIconPixels builds packed pixel data of the given size and depth, taking each value from value.
+func testIconPixels(size image.Point, depth int, value func(x, y int) uint8) []byte {
+	var b = make([]byte, size.X*depth/8*size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			idx := y*size.X + x
+			perByte := 8 / depth
+			b[idx/perByte] |= value(x, y) << uint(8-depth*(idx%perByte+1))
+		}
+	}
+	return b
+}
+
+func TestIconMembers(t *testing.T) {
+	value := func(x, y int) uint8 { return uint8(x + y) }
+
+	tests := []struct {
+		name    string
+		decode  func([]byte) (image.Image, error)
+		size    image.Point
+		depth   int
+		palette color.Palette
+	}{
+		{name: "icl4", decode: Icl4FromBytes, size: image.Pt(32, 32), depth: 4, palette: macSystemPalette4()},
+		{name: "icl8", decode: Icl8FromBytes, size: image.Pt(32, 32), depth: 8, palette: macSystemPalette8()},
+		{name: "ics4", decode: Ics4FromBytes, size: image.Pt(16, 16), depth: 4, palette: macSystemPalette4()},
+		{name: "ics8", decode: Ics8FromBytes, size: image.Pt(16, 16), depth: 8, palette: macSystemPalette8()},
+		{name: "icm4", decode: Icm4FromBytes, size: image.Pt(16, 12), depth: 4, palette: macSystemPalette4()},
+		{name: "icm8", decode: Icm8FromBytes, size: image.Pt(16, 12), depth: 8, palette: macSystemPalette8()},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mask := uint8(1)<<uint(tt.depth) - 1
+			b := testIconPixels(tt.size, tt.depth, func(x, y int) uint8 { return value(x, y) & mask })
+
+			got, err := tt.decode(b)
+			if err != nil {
+				t.Fatalf("%vFromBytes() error = %v", tt.name, err)
+			}
+
+			want := image.NewNRGBA(image.Rectangle{Max: tt.size})
+			for y := 0; y < tt.size.Y; y++ {
+				for x := 0; x < tt.size.X; x++ {
+					want.Set(x, y, tt.palette[value(x, y)&mask])
+				}
+			}
+
+			_, _, errs := fuzzyCompImage(got, want)
+			for _, err := range errs {
+				t.Errorf("fuzzyCompImage() error = %v", err)
+			}
+
+			if _, err := tt.decode(b[:len(b)-1]); err == nil {
+				t.Errorf("%vFromBytes() error = nil, want an error for short data", tt.name)
+			}
+		})
+	}
+}
+
+func TestIconListMembers(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func([]byte) (image.Image, *image.Alpha, error)
+		size   image.Point
+	}{
+		{name: "ics#", decode: IcsListFromBytes, size: image.Pt(16, 16)},
+		{name: "icm#", decode: IcmListFromBytes, size: image.Pt(16, 12)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := append(
+				testIconPixels(tt.size, 1, func(x, y int) uint8 { return uint8(x % 2) }),
+				testIconPixels(tt.size, 1, func(x, y int) uint8 { return uint8(y % 2) })...,
+			)
+
+			icon, mask, err := tt.decode(b)
+			if err != nil {
+				t.Fatalf("%v decode error = %v", tt.name, err)
+			}
+
+			if icon.Bounds().Size() != tt.size || mask.Bounds().Size() != tt.size {
+				t.Fatalf("%v decode sizes = %v and %v, want %v", tt.name, icon.Bounds().Size(), mask.Bounds().Size(), tt.size)
+			}
+
+			for y := 0; y < tt.size.Y; y++ {
+				for x := 0; x < tt.size.X; x++ {
+					c := color.NRGBAModel.Convert(icon.At(x, y)).(color.NRGBA)
+					wantAlpha := uint8(y%2) * 0xFF
+					if c.A != wantAlpha || mask.AlphaAt(x, y).A != wantAlpha {
+						t.Errorf("%v At(%v, %v) = %v with mask %v, want alpha %v", tt.name, x, y, c, mask.AlphaAt(x, y), wantAlpha)
+					}
+					if c.A != 0 && (c.R == 0) != (x%2 == 1) {
+						t.Errorf("%v At(%v, %v) = %v", tt.name, x, y, c)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIconFamilyFromResources(t *testing.T) {
+	large := image.Pt(32, 32)
+	small := image.Pt(16, 16)
+	inside := func(x, y int) uint8 {
+		if x >= 2 && y >= 2 {
+			return 1
+		}
+		return 0
+	}
+
+	resources := map[string][]byte{
+		"ICN#": append(testIconPixels(large, 1, inside), testIconPixels(large, 1, inside)...),
+		"icl8": testIconPixels(large, 8, func(x, y int) uint8 { return 5 }),
+		"icl4": testIconPixels(large, 4, func(x, y int) uint8 { return 3 }),
+		"ics4": testIconPixels(small, 4, func(x, y int) uint8 { return 6 }),
+		"icm8": testIconPixels(image.Pt(16, 12), 8, func(x, y int) uint8 { return 7 }),
+		"STR ": []byte("ignored"),
+	}
+
+	family, err := IconFamilyFromResources(resources)
+	if err != nil {
+		t.Fatalf("IconFamilyFromResources() error = %v", err)
+	}
+
+	if len(family.Members) != 5 {
+		t.Errorf("IconFamilyFromResources() has %v members, want 5", len(family.Members))
+	}
+
+	icl8 := family.Members["icl8"]
+	if _, _, _, a := icl8.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("icl8 At(0, 0) is not masked by the ICN#")
+	}
+	if c := icl8.At(10, 10); color.NRGBAModel.Convert(c) != color.NRGBAModel.Convert(macSystemPalette8()[5]) {
+		t.Errorf("icl8 At(10, 10) = %v, want %v", c, macSystemPalette8()[5])
+	}
+
+	// Without an ics#, the small icon is left opaque.
+	if _, _, _, a := family.Members["ics4"].At(0, 0).RGBA(); a != 0xFFFF {
+		t.Errorf("ics4 At(0, 0) is not opaque")
+	}
+
+	if best := family.Best(32); best != icl8 {
+		t.Errorf("Best(32) did not return the icl8")
+	}
+	if best := family.Best(16); best != family.Members["ics4"] {
+		t.Errorf("Best(16) did not return the ics4")
+	}
+	if best := family.Best(48); best != nil {
+		t.Errorf("Best(48) = %v, want nil", best)
+	}
+
+	resources["ICN#"] = resources["ICN#"][:10]
+	if _, err := IconFamilyFromResources(resources); err == nil {
+		t.Errorf("IconFamilyFromResources() error = nil, want an error for a short ICN#")
+	}
+}
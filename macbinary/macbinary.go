@@ -0,0 +1,161 @@
+// Package macbinary reads files wrapped in MacBinary I, II or III, the format classic Mac OS files are
+// usually downloaded in, to reach their data and resource forks.
+package macbinary
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	headerSize = 128
+	blockSize  = 128
+)
+
+// File is a file unwrapped from MacBinary.
+type File struct {
+	Name    string
+	Type    string
+	Creator string
+
+	// Version is 1, 2 or 3 for MacBinary I, II or III.
+	Version int
+
+	DataFork     []byte
+	ResourceFork []byte
+}
+
+// IsMacBinary reports whether b begins with a valid MacBinary header.
+func IsMacBinary(b []byte) bool {
+	_, err := parseHeader(b)
+	return err == nil
+}
+
+// Parse unwraps a MacBinary file. MacBinary II and III headers must match their CRC, while MacBinary I
+// headers, which have none, are recognised by their zeroed fields.
+func Parse(b []byte) (*File, error) {
+	version, err := parseHeader(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dataLength      = int(binary.BigEndian.Uint32(b[83:]))
+		resourceLength  = int(binary.BigEndian.Uint32(b[87:]))
+		secondaryLength = 0
+	)
+
+	if version > 1 {
+		secondaryLength = int(binary.BigEndian.Uint16(b[120:]))
+	}
+
+	var pos = headerSize + padded(secondaryLength)
+
+	dataFork, err := fork(b, pos, dataLength, "data")
+	if err != nil {
+		return nil, err
+	}
+	pos += padded(dataLength)
+
+	resourceFork, err := fork(b, pos, resourceLength, "resource")
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{
+		Name:         string(b[2 : 2+int(b[1])]),
+		Type:         string(b[65:69]),
+		Creator:      string(b[69:73]),
+		Version:      version,
+		DataFork:     dataFork,
+		ResourceFork: resourceFork,
+	}, nil
+}
+
+// ResourceForkFromPath reads the resource fork of the file at path. Files named .bin, or beginning with
+// what looks like a MacBinary header, are unwrapped and their errors returned, while any other file is
+// taken to be a bare resource fork.
+func ResourceForkFromPath(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".bin") && !hasMarkers(b) {
+		return b, nil
+	}
+
+	f, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return f.ResourceFork, nil
+}
+
+// parseHeader validates a MacBinary header and returns its version.
+func parseHeader(b []byte) (int, error) {
+	if len(b) < headerSize {
+		return 0, errors.New("MacBinary header is too short")
+	}
+
+	if !hasMarkers(b) {
+		return 0, errors.New("invalid MacBinary header")
+	}
+
+	if binary.BigEndian.Uint16(b[124:]) == crc16(b[:124]) {
+		if string(b[102:106]) == "mBIN" {
+			return 3, nil
+		}
+		return 2, nil
+	}
+
+	// MacBinary I leaves everything after the dates unused.
+	for _, v := range b[99:126] {
+		if v != 0 {
+			return 0, errors.New("MacBinary header CRC does not match")
+		}
+	}
+	return 1, nil
+}
+
+// hasMarkers reports whether b holds the fields that are zero in every version of the MacBinary header,
+// along with a file name of a valid length. A bare resource fork, whose data usually begins 256 bytes
+// in, fails on the length.
+func hasMarkers(b []byte) bool {
+	return len(b) >= headerSize && b[0] == 0 && b[74] == 0 && b[82] == 0 && b[1] >= 1 && b[1] <= 63
+}
+
+func fork(b []byte, pos int, length int, name string) ([]byte, error) {
+	if length == 0 {
+		return nil, nil
+	}
+	if length < 0 || pos+length > len(b) {
+		return nil, errors.New(fmt.Sprintf("MacBinary %s fork of %v bytes extends past the end of the file", name, length))
+	}
+	return b[pos : pos+length : pos+length], nil
+}
+
+// padded rounds length up to a whole number of blocks.
+func padded(length int) int {
+	return (length + blockSize - 1) / blockSize * blockSize
+}
+
+// crc16 computes the CRC-16/XMODEM checksum MacBinary II and III headers carry.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, v := range data {
+		crc ^= uint16(v) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,173 @@
+package macbinary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testFile(version int, data []byte, resource []byte) []byte {
+	var header = make([]byte, headerSize)
+	header[1] = 9
+	copy(header[2:], "Test File")
+	copy(header[65:], "APPL")
+	copy(header[69:], "TEST")
+	binary.BigEndian.PutUint32(header[83:], uint32(len(data)))
+	binary.BigEndian.PutUint32(header[87:], uint32(len(resource)))
+
+	if version > 1 {
+		header[122], header[123] = 129, 129
+		if version == 3 {
+			copy(header[102:], "mBIN")
+			header[122] = 130
+		}
+		binary.BigEndian.PutUint16(header[124:], crc16(header[:124]))
+	}
+
+	var b = append(header, data...)
+	b = append(b, make([]byte, padded(len(data))-len(data))...)
+	b = append(b, resource...)
+	return append(b, make([]byte, padded(len(resource))-len(resource))...)
+}
+
+func TestCRC16(t *testing.T) {
+	if got := crc16([]byte("123456789")); got != 0x31C3 {
+		t.Errorf("crc16() = %#x, want 0x31c3", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := bytes.Repeat([]byte{1, 2, 3}, 100)
+	resource := bytes.Repeat([]byte{9}, 129)
+
+	for _, version := range []int{1, 2, 3} {
+		version := version
+		t.Run(string(rune('0'+version)), func(t *testing.T) {
+			t.Parallel()
+
+			b := testFile(version, data, resource)
+
+			if !IsMacBinary(b) {
+				t.Errorf("IsMacBinary() = false, want true")
+			}
+
+			got, err := Parse(b)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got.Version != version {
+				t.Errorf("Parse() version = %v, want %v", got.Version, version)
+			}
+			if got.Name != "Test File" || got.Type != "APPL" || got.Creator != "TEST" {
+				t.Errorf("Parse() = %q, type %q, creator %q", got.Name, got.Type, got.Creator)
+			}
+			if !bytes.Equal(got.DataFork, data) {
+				t.Errorf("Parse() data fork = %v bytes, want %v", len(got.DataFork), len(data))
+			}
+			if !bytes.Equal(got.ResourceFork, resource) {
+				t.Errorf("Parse() resource fork = %v bytes, want %v", len(got.ResourceFork), len(resource))
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	valid := testFile(2, []byte{1, 2, 3}, []byte{4, 5, 6})
+
+	corrupt := func(f func(b []byte)) []byte {
+		b := append([]byte(nil), valid...)
+		f(b)
+		return b
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "short", b: valid[:100]},
+		{name: "marker", b: corrupt(func(b []byte) { b[74] = 1 })},
+		{name: "name", b: corrupt(func(b []byte) { b[1] = 0 })},
+		{name: "crc", b: corrupt(func(b []byte) { b[10] ^= 0xFF })},
+		{name: "fork", b: corrupt(func(b []byte) { binary.BigEndian.PutUint32(b[87:], 1000) })},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := Parse(tt.b); err == nil {
+				t.Errorf("Parse() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestResourceForkFromPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "macbinary")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	resource := []byte("resource fork")
+
+	wrapped := filepath.Join(dir, "wrapped.bin")
+	if err := ioutil.WriteFile(wrapped, testFile(2, []byte("data fork"), resource), 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile() error = %v", err)
+	}
+
+	bare := filepath.Join(dir, "bare.rsrc")
+	if err := ioutil.WriteFile(bare, resource, 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile() error = %v", err)
+	}
+
+	for _, path := range []string{wrapped, bare} {
+		got, err := ResourceForkFromPath(path)
+		if err != nil {
+			t.Fatalf("ResourceForkFromPath(%v) error = %v", path, err)
+		}
+		if !bytes.Equal(got, resource) {
+			t.Errorf("ResourceForkFromPath(%v) = %q, want %q", path, got, resource)
+		}
+	}
+}
+
+func TestResourceForkFromPath_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "macbinary")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	badCRC := testFile(2, []byte("data fork"), []byte("resource fork"))
+	badCRC[10] ^= 0xFF
+
+	shortFork := testFile(2, nil, []byte("resource fork"))
+	shortFork = shortFork[:headerSize+4]
+
+	tests := []struct {
+		name string
+		file string
+		b    []byte
+	}{
+		{name: "crc", file: "crc.rsrc", b: badCRC},
+		{name: "fork", file: "fork.rsrc", b: shortFork},
+		{name: "extension", file: "short.bin", b: []byte("resource fork")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := ioutil.WriteFile(path, tt.b, 0600); err != nil {
+				t.Fatalf("ioutil.WriteFile() error = %v", err)
+			}
+
+			if _, err := ResourceForkFromPath(path); err == nil {
+				t.Errorf("ResourceForkFromPath() error = nil, want the MacBinary error")
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/imle/gomacimage/macbinary"
+)
+
+const (
+	macPaintWidth      = 576
+	macPaintHeight     = 720
+	macPaintRowBytes   = macPaintWidth / 8
+	macPaintHeaderSize = 512
+)
+
+// MacPaintPalette is the palette of decoded MacPaint images. Clear bits are white and set bits black.
+var MacPaintPalette = color.Palette{color.White, color.Black}
+
+// MacPaintFromBytes decodes a MacPaint (PNTG) document: a 512 byte header followed by 720 rows of 576
+// pixels, each row packed with PackBits. Documents still wrapped in MacBinary, as they are usually
+// downloaded, are unwrapped first.
+func MacPaintFromBytes(b []byte) (*image.Paletted, error) {
+	b, err := macPaintDocument(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		parser dataStructureParse
+		data   = NewBigEndianDataView(b[macPaintHeaderSize:])
+		img    = image.NewPaletted(image.Rect(0, 0, macPaintWidth, macPaintHeight), MacPaintPalette)
+	)
+
+	for y := 0; y < macPaintHeight; y++ {
+		row, read, err := parser.packBitsDecodeLimit(1, data, macPaintRowBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(row) < macPaintRowBytes {
+			return nil, errors.New(fmt.Sprintf("MacPaint document ended at row %v of %v", y, macPaintHeight))
+		}
+
+		for x := 0; x < macPaintWidth; x++ {
+			img.Pix[y*img.Stride+x] = readBit(row, macPaintRowBytes, x, 0)
+		}
+
+		data = NewBigEndianDataView(data.buffer[read:])
+	}
+
+	return img, nil
+}
+
+// macPaintDocument returns the bare document held by b, unwrapping it from MacBinary, once its header
+// is found to begin with a version of 0, 2 or 3.
+func macPaintDocument(b []byte) ([]byte, error) {
+	b = stripMacPaintMacBinary(b)
+
+	if len(b) < macPaintHeaderSize {
+		return nil, errors.New("MacPaint document is too short")
+	}
+
+	if version := NewBigEndianDataView(b).GetUint32(0); version != 0 && version != 2 && version != 3 {
+		return nil, errors.New(fmt.Sprintf("unknown MacPaint version %v", version))
+	}
+
+	return b, nil
+}
+
+// stripMacPaintMacBinary returns the data fork of a MacPaint document wrapped in MacBinary, leaving
+// other data untouched.
+func stripMacPaintMacBinary(b []byte) []byte {
+	if f, err := macbinary.Parse(b); err == nil && f.Type == "PNTG" {
+		return f.DataFork
+	}
+	return b
+}
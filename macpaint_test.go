@@ -0,0 +1,120 @@
+package gomacimage
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+// testMacPaint builds a bare MacPaint document whose pixels are set where set returns true.
+func testMacPaint(set func(x, y int) bool) []byte {
+	var b = make([]byte, macPaintHeaderSize)
+	b[3] = 2
+
+	for y := 0; y < macPaintHeight; y++ {
+		row := make([]byte, macPaintRowBytes)
+		for x := 0; x < macPaintWidth; x++ {
+			if set(x, y) {
+				row[x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+		b = append(b, packBitsEncode(1, row)...)
+	}
+
+	// Documents are padded out to a whole number of blocks.
+	return append(b, make([]byte, 300)...)
+}
+
+func testMacBinary(fileType string, data []byte) []byte {
+	var header = make([]byte, 128)
+	header[1] = 5
+	copy(header[2:], "paint")
+	copy(header[65:], fileType)
+	copy(header[69:], "MPNT")
+	header[83], header[84], header[85], header[86] = uint8(len(data)>>24), uint8(len(data)>>16), uint8(len(data)>>8), uint8(len(data))
+	return append(header, data...)
+}
+
+func TestMacPaintFromBytes(t *testing.T) {
+	set := func(x, y int) bool { return x%7 == 0 || y%5 == 0 || (x > 100 && x < 400 && y > 200 && y < 300) }
+	doc := testMacPaint(set)
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "bare", b: doc},
+		{name: "MacBinary", b: testMacBinary("PNTG", doc)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := MacPaintFromBytes(tt.b)
+			if err != nil {
+				t.Fatalf("MacPaintFromBytes() error = %v", err)
+			}
+
+			if got.Bounds() != image.Rect(0, 0, 576, 720) {
+				t.Fatalf("MacPaintFromBytes() bounds = %v, want %v", got.Bounds(), image.Rect(0, 0, 576, 720))
+			}
+
+			for y := 0; y < 720; y++ {
+				for x := 0; x < 576; x++ {
+					if want := set(x, y); (got.ColorIndexAt(x, y) == 1) != want {
+						t.Fatalf("MacPaintFromBytes().ColorIndexAt(%v, %v) = %v, want set = %v", x, y, got.ColorIndexAt(x, y), want)
+					}
+				}
+			}
+
+			config, err := MacPaintDecodeConfig(bytes.NewReader(tt.b))
+			if err != nil {
+				t.Fatalf("MacPaintDecodeConfig() error = %v", err)
+			}
+			if config.Width != 576 || config.Height != 720 {
+				t.Errorf("MacPaintDecodeConfig() size = %vx%v, want 576x720", config.Width, config.Height)
+			}
+		})
+	}
+}
+
+func TestImageDecode_MacPaint(t *testing.T) {
+	doc := testMacPaint(func(x, y int) bool { return x == y })
+
+	decoded, format, err := image.Decode(bytes.NewReader(testMacBinary("PNTG", doc)))
+	if err != nil {
+		t.Fatalf("image.Decode() error = %v", err)
+	}
+	if format != "pntg" || decoded.Bounds() != image.Rect(0, 0, 576, 720) {
+		t.Errorf("image.Decode() = %v image of %v, want pntg", format, decoded.Bounds())
+	}
+
+	// Bare documents are not sniffed, so other data beginning like them is left alone.
+	if _, _, err := image.Decode(bytes.NewReader(doc)); err != image.ErrFormat {
+		t.Errorf("image.Decode() error = %v, want image.ErrFormat for a bare document", err)
+	}
+}
+
+func TestMacPaintFromBytes_Invalid(t *testing.T) {
+	doc := testMacPaint(func(x, y int) bool { return (x+y)%3 == 0 })
+
+	if _, err := MacPaintFromBytes(doc[:100]); err == nil {
+		t.Errorf("MacPaintFromBytes() error = nil, want an error for a missing header")
+	}
+	if _, err := MacPaintFromBytes(doc[:macPaintHeaderSize+2000]); err == nil {
+		t.Errorf("MacPaintFromBytes() error = nil, want an error for missing rows")
+	}
+
+	unknown := append([]byte(nil), doc...)
+	unknown[0] = 0x47
+	if _, err := MacPaintFromBytes(unknown); err == nil {
+		t.Errorf("MacPaintFromBytes() error = nil, want an error for an unknown version")
+	}
+
+	for _, b := range [][]byte{doc[:100], unknown, testMacBinary("PNTG", unknown)} {
+		if _, err := MacPaintDecodeConfig(bytes.NewReader(b)); err == nil {
+			t.Errorf("MacPaintDecodeConfig() error = nil, want an error")
+		}
+	}
+}
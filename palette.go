@@ -0,0 +1,147 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+)
+
+// The standard color lookup tables of Mac OS, as used for pixels of each depth when no other color
+// table is given. The color tables of 1 and 2 bits per pixel hold only grays.
+var (
+	SystemPalette1 = macGrayPalette(1)
+	SystemPalette2 = macGrayPalette(2)
+	SystemPalette4 = macSystemPalette4()
+	SystemPalette8 = macSystemPalette8()
+)
+
+// The standard gray lookup tables of Mac OS (clut 33, 34, 36 and 40), running from white to black.
+var (
+	SystemGrayPalette1 = macGrayPalette(1)
+	SystemGrayPalette2 = macGrayPalette(2)
+	SystemGrayPalette4 = macGrayPalette(4)
+	SystemGrayPalette8 = macGrayPalette(8)
+)
+
+// PaletteFromColorTable decodes a ColorTable, as found in PixMaps and clut resources, into a palette
+// indexed by pixel value. Entries of a device table (ctFlags bit 15 set) are indexed by position rather
+// than by their value, and an empty table (ctSize 0xFFFF) stands for the standard table whose ID is
+// its seed. Values without an entry are black.
+func PaletteFromColorTable(b []byte) (pal color.Palette, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pal = nil
+
+			switch x := r.(type) {
+			case string:
+				err = errors.New(x)
+			case error:
+				err = x
+			default:
+				err = errors.New(fmt.Sprintf("unknown panic: %v", r))
+			}
+		}
+	}()
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	return parser.parseColorTable().palette(), nil
+}
+
+// systemPaletteByID returns the standard color lookup table with the given resource ID.
+func systemPaletteByID(id uint32) (color.Palette, bool) {
+	switch id {
+	case 1, 33:
+		return SystemPalette1, true
+	case 2:
+		return SystemPalette2, true
+	case 4:
+		return SystemPalette4, true
+	case 8:
+		return SystemPalette8, true
+	case 34:
+		return SystemGrayPalette2, true
+	case 36:
+		return SystemGrayPalette4, true
+	case 40:
+		return SystemGrayPalette8, true
+	}
+	return nil, false
+}
+
+// macGrayPalette returns evenly spaced grays from white to black for pixels of the given depth.
+func macGrayPalette(depth uint) color.Palette {
+	var (
+		count = 1 << depth
+		pal   = make(color.Palette, count)
+	)
+
+	for i := range pal {
+		v := uint16(0xFFFF - i*0xFFFF/(count-1))
+		pal[i] = color.RGBA64{R: v, G: v, B: v, A: 0xFFFF}
+	}
+	return pal
+}
+
+// macSystemPalette8 returns the Mac OS 256 color system palette (clut 8). It starts with a 6x6x6 color
+// cube running from white to just above black, followed by ramps of red, green, blue and gray using
+// the levels the cube skips, and ends with black.
+func macSystemPalette8() color.Palette {
+	var (
+		pal    = make(color.Palette, 0, 256)
+		cube   = []uint8{0xFF, 0xCC, 0x99, 0x66, 0x33, 0x00}
+		ramp   = []uint8{0xEE, 0xDD, 0xBB, 0xAA, 0x88, 0x77, 0x55, 0x44, 0x22, 0x11}
+		opaque = func(r, g, b uint8) color.Color { return color.RGBA{R: r, G: g, B: b, A: 0xFF} }
+	)
+
+	for _, r := range cube {
+		for _, g := range cube {
+			for _, b := range cube {
+				pal = append(pal, opaque(r, g, b))
+			}
+		}
+	}
+
+	// The last entry of the cube is black, which is moved to the end of the palette.
+	pal = pal[:len(pal)-1]
+
+	for _, v := range ramp {
+		pal = append(pal, opaque(v, 0, 0))
+	}
+	for _, v := range ramp {
+		pal = append(pal, opaque(0, v, 0))
+	}
+	for _, v := range ramp {
+		pal = append(pal, opaque(0, 0, v))
+	}
+	for _, v := range ramp {
+		pal = append(pal, opaque(v, v, v))
+	}
+
+	return append(pal, opaque(0, 0, 0))
+}
+
+// macSystemPalette4 returns the Mac OS 16 color system palette (clut 4).
+func macSystemPalette4() color.Palette {
+	return color.Palette{
+		color.RGBA64{R: 0xFFFF, G: 0xFFFF, B: 0xFFFF, A: 0xFFFF}, // white
+		color.RGBA64{R: 0xFC00, G: 0xF37D, B: 0x052F, A: 0xFFFF}, // yellow
+		color.RGBA64{R: 0xFFFF, G: 0x648A, B: 0x028C, A: 0xFFFF}, // orange
+		color.RGBA64{R: 0xDD6B, G: 0x08C2, B: 0x06A2, A: 0xFFFF}, // red
+		color.RGBA64{R: 0xF2D7, G: 0x0856, B: 0x84EC, A: 0xFFFF}, // magenta
+		color.RGBA64{R: 0x46E3, G: 0x0000, B: 0xA53E, A: 0xFFFF}, // purple
+		color.RGBA64{R: 0x0000, G: 0x0000, B: 0xD400, A: 0xFFFF}, // blue
+		color.RGBA64{R: 0x0241, G: 0xAB54, B: 0xEAFF, A: 0xFFFF}, // cyan
+		color.RGBA64{R: 0x1F21, G: 0xB793, B: 0x1431, A: 0xFFFF}, // green
+		color.RGBA64{R: 0x0000, G: 0x64AF, B: 0x11B0, A: 0xFFFF}, // dark green
+		color.RGBA64{R: 0x5600, G: 0x2C9D, B: 0x0524, A: 0xFFFF}, // brown
+		color.RGBA64{R: 0x90D7, G: 0x7160, B: 0x3A34, A: 0xFFFF}, // tan
+		color.RGBA64{R: 0xC000, G: 0xC000, B: 0xC000, A: 0xFFFF}, // light gray
+		color.RGBA64{R: 0x8000, G: 0x8000, B: 0x8000, A: 0xFFFF}, // medium gray
+		color.RGBA64{R: 0x4000, G: 0x4000, B: 0x4000, A: 0xFFFF}, // dark gray
+		color.RGBA64{R: 0x0000, G: 0x0000, B: 0x0000, A: 0xFFFF}, // black
+	}
+}
@@ -0,0 +1,186 @@
+package gomacimage
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestSystemPalettes(t *testing.T) {
+	tests := []struct {
+		name  string
+		pal   color.Palette
+		count int
+		first color.Color
+		last  color.Color
+	}{
+		{name: "1-bit", pal: SystemPalette1, count: 2, first: color.White, last: color.Black},
+		{name: "2-bit", pal: SystemPalette2, count: 4, first: color.White, last: color.Black},
+		{name: "4-bit", pal: SystemPalette4, count: 16, first: color.White, last: color.Black},
+		{name: "8-bit", pal: SystemPalette8, count: 256, first: color.White, last: color.Black},
+		{name: "1-bit gray", pal: SystemGrayPalette1, count: 2, first: color.White, last: color.Black},
+		{name: "2-bit gray", pal: SystemGrayPalette2, count: 4, first: color.White, last: color.Black},
+		{name: "4-bit gray", pal: SystemGrayPalette4, count: 16, first: color.White, last: color.Black},
+		{name: "8-bit gray", pal: SystemGrayPalette8, count: 256, first: color.White, last: color.Black},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if len(tt.pal) != tt.count {
+				t.Fatalf("palette has %v colors, want %v", len(tt.pal), tt.count)
+			}
+
+			same := func(a, b color.Color) bool {
+				return color.RGBA64Model.Convert(a) == color.RGBA64Model.Convert(b)
+			}
+			if !same(tt.pal[0], tt.first) || !same(tt.pal[tt.count-1], tt.last) {
+				t.Errorf("palette runs from %v to %v, want %v to %v", tt.pal[0], tt.pal[tt.count-1], tt.first, tt.last)
+			}
+		})
+	}
+
+	if g := color.GrayModel.Convert(SystemGrayPalette2[1]).(color.Gray); g.Y != 0xAA {
+		t.Errorf("SystemGrayPalette2[1] = %v, want a gray of 0xAA", g)
+	}
+}
+
+func TestMacSystemPalette8(t *testing.T) {
+	pal := macSystemPalette8()
+	if len(pal) != 256 {
+		t.Fatalf("macSystemPalette8() has %v colors, want 256", len(pal))
+	}
+
+	tests := []struct {
+		index int
+		want  color.RGBA
+	}{
+		{index: 0, want: color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}},
+		{index: 5, want: color.RGBA{R: 0xFF, G: 0xFF, B: 0x00, A: 0xFF}},
+		{index: 214, want: color.RGBA{R: 0x00, G: 0x00, B: 0x33, A: 0xFF}},
+		{index: 215, want: color.RGBA{R: 0xEE, A: 0xFF}},
+		{index: 235, want: color.RGBA{B: 0xEE, A: 0xFF}},
+		{index: 254, want: color.RGBA{R: 0x11, G: 0x11, B: 0x11, A: 0xFF}},
+		{index: 255, want: color.RGBA{A: 0xFF}},
+	}
+	for _, tt := range tests {
+		if got := pal[tt.index]; got != tt.want {
+			t.Errorf("macSystemPalette8()[%v] = %v, want %v", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestColorTable(t *testing.T) {
+	rows := []colorRow{
+		{value: 3, r: 0xFFFF},
+		{value: 0, g: 0xFFFF},
+		{value: 1, b: 0xFFFF},
+	}
+
+	red := color.NRGBA{R: 0xFF, A: 0xFF}
+	green := color.NRGBA{G: 0xFF, A: 0xFF}
+	blue := color.NRGBA{B: 0xFF, A: 0xFF}
+	black := color.NRGBA{A: 0xFF}
+
+	tests := []struct {
+		name    string
+		ct      colorTable
+		lookups map[uint16]color.NRGBA
+		palette color.Palette
+	}{
+		{
+			name:    "by value",
+			ct:      colorTable{size: 3, data: rows},
+			lookups: map[uint16]color.NRGBA{0: green, 1: blue, 3: red},
+			palette: color.Palette{green, blue, black, red},
+		},
+		{
+			name:    "device",
+			ct:      colorTable{flags: 0x8000, size: 3, data: rows},
+			lookups: map[uint16]color.NRGBA{0: red, 1: green, 2: blue},
+			palette: color.Palette{red, green, blue},
+		},
+		{
+			name:    "standard by seed",
+			ct:      colorTable{seed: 4},
+			lookups: map[uint16]color.NRGBA{0: {R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}, 15: black},
+			palette: SystemPalette4,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for value, want := range tt.lookups {
+				got, ok := tt.ct.lookup(value)
+				if !ok || got != want {
+					t.Errorf("lookup(%v) = %v, %v, want %v", value, got, ok, want)
+				}
+			}
+
+			if _, ok := tt.ct.lookup(200); ok {
+				t.Errorf("lookup(200) found an entry")
+			}
+
+			if got := tt.ct.palette(); !reflect.DeepEqual(got, tt.palette) {
+				t.Errorf("palette() = %v, want %v", got, tt.palette)
+			}
+		})
+	}
+}
+
+func TestPaletteFromColorTable(t *testing.T) {
+	red := color.NRGBA{R: 0xFF, A: 0xFF}
+	blue := color.NRGBA{B: 0xFF, A: 0xFF}
+	black := color.NRGBA{A: 0xFF}
+
+	tests := []struct {
+		name string
+		b    []byte
+		want color.Palette
+	}{
+		{
+			name: "by value",
+			b: []byte{
+				0, 0, 0, 0, 0x00, 0x00, 0, 1,
+				0, 2, 0xFF, 0xFF, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0, 0xFF, 0xFF,
+			},
+			want: color.Palette{blue, black, red},
+		},
+		{
+			name: "device",
+			b: []byte{
+				0, 0, 0, 0, 0x80, 0x00, 0, 1,
+				0, 2, 0xFF, 0xFF, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0, 0xFF, 0xFF,
+			},
+			want: color.Palette{red, blue},
+		},
+		{
+			name: "empty table by seed",
+			b:    []byte{0, 0, 0, 8, 0x00, 0x00, 0xFF, 0xFF},
+			want: SystemPalette8,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := PaletteFromColorTable(tt.b)
+			if err != nil {
+				t.Fatalf("PaletteFromColorTable() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PaletteFromColorTable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := PaletteFromColorTable([]byte{0, 0, 0, 0, 0, 0, 0, 3, 0, 0}); err == nil {
+		t.Errorf("PaletteFromColorTable() error = nil, want an error for a truncated table")
+	}
+}
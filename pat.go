@@ -0,0 +1,142 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+const patternDataSize = 8
+
+// Pattern is a decoded PAT, PAT# or ppat pattern.
+type Pattern struct {
+	// Tile is a single repetition of the pattern.
+	Tile image.Image
+
+	// Bits is the 8x8 black and white pattern, which pixel patterns also carry for 1-bit screens. Each
+	// byte holds a row, with the leftmost pixel in the high bit and set bits drawn in black.
+	Bits [8]uint8
+}
+
+// TileTo repeats the tile of the pattern over an image of w by h pixels, starting with the top left
+// corner of the tile. A missing or empty tile leaves the image transparent.
+func (p *Pattern) TileTo(w int, h int) image.Image {
+	var img = image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	if p.Tile == nil || p.Tile.Bounds().Empty() {
+		return img
+	}
+
+	var (
+		pat = pattern{tile: p.Tile}
+		min = p.Tile.Bounds().Min
+	)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, pat.at(min.X+x, min.Y+y))
+		}
+	}
+
+	return img
+}
+
+// PatFromBytes decodes a PAT resource, an 8x8 black and white pattern.
+func PatFromBytes(b []byte) (*Pattern, error) {
+	if len(b) < patternDataSize {
+		return nil, errors.New(fmt.Sprintf("PAT resource is %v bytes, expected %v", len(b), patternDataSize))
+	}
+
+	return bitsPattern(b[:patternDataSize]), nil
+}
+
+// PatListFromBytes decodes a PAT# resource, a count followed by that many 8x8 black and white patterns.
+func PatListFromBytes(b []byte) ([]*Pattern, error) {
+	if len(b) < 2 {
+		return nil, errors.New("PAT# resource is missing its pattern count")
+	}
+
+	var count = int(b[0])<<8 | int(b[1])
+	if len(b) < 2+count*patternDataSize {
+		return nil, errors.New(fmt.Sprintf("PAT# resource is %v bytes, expected %v", len(b), 2+count*patternDataSize))
+	}
+
+	var patterns = make([]*Pattern, count)
+	for i := range patterns {
+		start := 2 + i*patternDataSize
+		patterns[i] = bitsPattern(b[start : start+patternDataSize])
+	}
+	return patterns, nil
+}
+
+// bitsPattern builds a pattern from the 8 bytes of a black and white pattern.
+func bitsPattern(data []uint8) *Pattern {
+	var pat = &Pattern{
+		Tile: bitMapImage(data, nil, 1, 8, 8),
+	}
+	copy(pat.Bits[:], data)
+	return pat
+}
+
+// PpatFromBytes decodes a ppat resource. Old style patterns only hold black and white bits, while full
+// pixel patterns have their own PixMap, color table and pixel data, found through offsets within the
+// resource.
+func PpatFromBytes(b []byte) (pat *Pattern, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pat = nil
+
+			switch x := r.(type) {
+			case string:
+				err = errors.New(x)
+			case error:
+				err = x
+			default:
+				err = errors.New(fmt.Sprintf("unknown panic: %v", r))
+			}
+		}
+	}()
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	var (
+		patType = parser.readWord()
+		patMap  = parser.readDWord()
+		patData = parser.readDWord()
+		_       = parser.readDWord() // patXData
+		_       = parser.readWord()  // patXValid
+		_       = parser.readDWord() // patXMap
+		bits    = parser.readDataUint8(patternDataSize)
+	)
+
+	pat = bitsPattern(bits)
+
+	switch patType {
+	case 0:
+		return pat, nil
+	case 1, 2:
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown ppat pattern type: %v", patType))
+	}
+
+	parser.pos = int(patMap)
+	var px = parser.parsePixMap()
+
+	parser.pos = int(px.pmTable)
+	var ct = parser.parseColorTable()
+
+	parser.pos = int(patData)
+	rows, err := parser.readPackedRows(px.rowBytes, px.bounds.height, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pat.Tile, err = decodeIndexedRows(px, ct, rows)
+	if err != nil {
+		return nil, err
+	}
+	return pat, nil
+}
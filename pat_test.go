@@ -0,0 +1,167 @@
+package gomacimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var testPatternBits = []byte{0x80, 0x40, 0x20, 0x10, 0x08, 0x04, 0x02, 0x01}
+
+func checkPatternBits(t *testing.T, img image.Image, w, h int) {
+	t.Helper()
+
+	if img.Bounds() != image.Rect(0, 0, w, h) {
+		t.Fatalf("bounds = %v, want %v", img.Bounds(), image.Rect(0, 0, w, h))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, _, _, a := img.At(x, y).RGBA()
+			if a != 0xFFFF || (r == 0) != (x%8 == y%8) {
+				t.Errorf("At(%v, %v) = %v", x, y, img.At(x, y))
+			}
+		}
+	}
+}
+
+func TestPatFromBytes(t *testing.T) {
+	got, err := PatFromBytes(testPatternBits)
+	if err != nil {
+		t.Fatalf("PatFromBytes() error = %v", err)
+	}
+
+	checkPatternBits(t, got.Tile, 8, 8)
+	checkPatternBits(t, got.TileTo(21, 13), 21, 13)
+
+	if _, err := PatFromBytes(testPatternBits[:4]); err == nil {
+		t.Errorf("PatFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestPatListFromBytes(t *testing.T) {
+	b := []byte{0, 3}
+	b = append(b, testPatternBits...)
+	b = append(b, make([]byte, 8)...)
+	b = append(b, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+
+	got, err := PatListFromBytes(b)
+	if err != nil {
+		t.Fatalf("PatListFromBytes() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("PatListFromBytes() returned %v patterns, want 3", len(got))
+	}
+
+	checkPatternBits(t, got[0].Tile, 8, 8)
+	if c := color.GrayModel.Convert(got[1].Tile.At(3, 3)).(color.Gray); c.Y != 0xFF {
+		t.Errorf("PatListFromBytes()[1].At(3, 3) = %v, want white", c)
+	}
+	if c := color.GrayModel.Convert(got[2].Tile.At(3, 3)).(color.Gray); c.Y != 0 {
+		t.Errorf("PatListFromBytes()[2].At(3, 3) = %v, want black", c)
+	}
+
+	if _, err := PatListFromBytes(b[:20]); err == nil {
+		t.Errorf("PatListFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestPpatFromBytes(t *testing.T) {
+	colors := []color.NRGBA{
+		{R: 0xFF, A: 0xFF},
+		{G: 0xFF, A: 0xFF},
+		{B: 0xFF, A: 0xFF},
+		{R: 0xFF, G: 0xFF, A: 0xFF},
+	}
+
+	const (
+		patMap  = 28
+		ctTable = patMap + 50
+		patData = ctTable + 8 + 4*8
+	)
+
+	var w dataStructureWrite
+	w.writeWord(1) // full pixel pattern
+	w.writeDWord(patMap)
+	w.writeDWord(patData)
+	w.writeDWord(0)
+	w.writeWord(0)
+	w.writeDWord(0)
+	w.writeData(testPatternBits)
+
+	// 8x2 PixMap at 2 bits per pixel.
+	w.writeDWord(0)
+	w.writeWord(0x8000 | 2)
+	w.writeQDRect(image.Rect(0, 0, 8, 2))
+	w.writeWord(0)
+	w.writeWord(0)
+	w.writeDWord(0)
+	w.writeDWord(0x00480000)
+	w.writeDWord(0x00480000)
+	w.writeWord(0)
+	w.writeWord(2)
+	w.writeWord(1)
+	w.writeWord(2)
+	w.writeDWord(0)
+	w.writeDWord(ctTable)
+	w.writeDWord(0)
+
+	w.writeDWord(0)
+	w.writeWord(0)
+	w.writeWord(uint16(len(colors) - 1))
+	for i, c := range colors {
+		w.writeWord(uint16(i))
+		w.writeWord(uint16(c.R) * 0x101)
+		w.writeWord(uint16(c.G) * 0x101)
+		w.writeWord(uint16(c.B) * 0x101)
+	}
+
+	// Pixel values 0 1 2 3 0 1 2 3, then 3 2 1 0 3 2 1 0.
+	w.writeData([]byte{0x1B, 0x1B, 0xE4, 0xE4})
+
+	got, err := PpatFromBytes(w.b.Bytes())
+	if err != nil {
+		t.Fatalf("PpatFromBytes() error = %v", err)
+	}
+
+	if got.Bits != [8]uint8{0x80, 0x40, 0x20, 0x10, 0x08, 0x04, 0x02, 0x01} {
+		t.Errorf("PpatFromBytes() bits = %v", got.Bits)
+	}
+
+	tiled := got.TileTo(11, 5)
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 11; x++ {
+			want := colors[x%4]
+			if y%2 == 1 {
+				want = colors[3-x%4]
+			}
+			if c := tiled.At(x, y); c != want {
+				t.Errorf("TileTo().At(%v, %v) = %v, want %v", x, y, c, want)
+			}
+		}
+	}
+
+	old := append([]byte(nil), w.b.Bytes()[:28]...)
+	old[1] = 0
+	got, err = PpatFromBytes(old)
+	if err != nil {
+		t.Fatalf("PpatFromBytes() error = %v", err)
+	}
+	checkPatternBits(t, got.Tile, 8, 8)
+
+	if _, err := PpatFromBytes(w.b.Bytes()[:60]); err == nil {
+		t.Errorf("PpatFromBytes() error = nil, want an error for short data")
+	}
+}
+
+func TestPattern_TileToEmpty(t *testing.T) {
+	for _, p := range []*Pattern{{}, {Tile: image.NewNRGBA(image.Rectangle{})}} {
+		got := p.TileTo(3, 2)
+		if got.Bounds() != image.Rect(0, 0, 3, 2) {
+			t.Fatalf("TileTo() bounds = %v, want %v", got.Bounds(), image.Rect(0, 0, 3, 2))
+		}
+		if _, _, _, a := got.At(1, 1).RGBA(); a != 0 {
+			t.Errorf("TileTo().At(1, 1) is not transparent")
+		}
+	}
+}
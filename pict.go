@@ -31,10 +31,13 @@
 package gomacimage
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"io"
+	"io/ioutil"
 	"math"
 )
 
@@ -43,13 +46,82 @@ type PictOpCode uint16
 const (
 	PictOpCodeNop            PictOpCode = 0x0000
 	PictOpCodeClipRegion                = 0x0001
+	PictOpCodeBitsRect                  = 0x0090
+	PictOpCodeBitsRgn                   = 0x0091
+	PictOpCodePackBitsRect              = 0x0098
+	PictOpCodePackBitsRgn               = 0x0099
 	PictOpCodeDirectBitsRect            = 0x009A
 	PictOpCodeEof                       = 0x00FF
 	PictOpCodeDefHiLite                 = 0x001E
+	PictOpCodeShortComment              = 0x00A0
 	PictOpCodeLongComment               = 0x00A1
 	PictOpCodeExtHeader                 = 0x0C00
 )
 
+// PICT files on disk begin with a header reserved for application use, which is not part of the picture.
+const pictFileHeaderSize = 512
+
+// PictFromFile decodes a PICT file, with or without its file header.
+func PictFromFile(path string) (image.Image, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return PictFromBytes(stripPictFileHeader(b))
+}
+
+// PictFromReader decodes a PICT read from r, with or without its file header.
+func PictFromReader(r io.Reader) (image.Image, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return PictFromBytes(stripPictFileHeader(b))
+}
+
+// stripPictFileHeader removes the file header from a PICT file. The header is detected by looking for
+// the version marker following the size and frame, which sits at offset 10 of raw picture data and at
+// offset 522 when the header is present.
+func stripPictFileHeader(b []byte) []byte {
+	var hasMarker = func(offset int, marker []byte) bool {
+		return len(b) >= offset+len(marker) && bytes.Equal(b[offset:offset+len(marker)], marker)
+	}
+
+	var (
+		version1 = []byte{0x11, 0x01}
+		version2 = []byte{0x00, 0x11, 0x02, 0xFF}
+		offset   = pictFileHeaderSize + 2 + 8
+	)
+
+	switch {
+	case hasMarker(10, version2):
+		return b
+	case hasMarker(offset, version2):
+		return b[pictFileHeaderSize:]
+	case hasMarker(10, version1):
+		return b
+	case hasMarker(offset, version1):
+		return b[pictFileHeaderSize:]
+	}
+	return b
+}
+
+// pictParser holds the drawing state of a PICT as its opcodes are played back.
+type pictParser struct {
+	dataStructureParse
+	quickDrawState
+
+	// canvas covers the picture frame and every drawing operation renders into it.
+	canvas *image.NRGBA
+
+	// clip is the most recent clip region, in canvas coordinates. Nothing is drawn outside of it.
+	clip *Region
+}
+
+func (p *pictParser) clipped(x, y int) bool {
+	return p.clip == nil || p.clip.Contains(x, y)
+}
+
 func PictFromBytes(b []byte) (img image.Image, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -66,21 +138,32 @@ func PictFromBytes(b []byte) (img image.Image, err error) {
 		}
 	}()
 
-	parser := dataStructureParse{
-		d:   NewBigEndianDataView(b),
-		pos: 0,
+	parser := pictParser{
+		dataStructureParse: dataStructureParse{
+			d:   NewBigEndianDataView(b),
+			pos: 0,
+		},
+		quickDrawState: newQuickDrawState(),
 	}
 
 	// The first word appears to be unused so skip it.
 	parser.pos += WordSize
 
-	// The first part of the PICT is the frame.
+	// The first part of the PICT is the frame. Everything drawn is composited into a canvas of this size.
 	frame := parser.readQDRect()
+	parser.canvas = image.NewNRGBA(frame.rectangle())
+
+	// Version 1 pictures follow the frame with the one byte version opcode and a version of 1. They have
+	// no header and are always drawn at 72 dpi.
+	if parser.d.GetUint16(parser.pos) == 0x1101 {
+		parser.pos += WordSize
+		return parser.parsePictOpCodes(1)
+	}
 
-	// The next 4 bytes are the version of the PICT. We're only interested in version 2.
+	// Otherwise the next 4 bytes are the version of the PICT, which must be version 2.
 	version := parser.readDWord()
 	if version != 0x001102ff {
-		return nil, errors.New("PICT resource is not version 2")
+		return nil, errors.New("PICT resource is not version 1 or 2")
 	}
 
 	// Ensure we have an extended header here.
@@ -92,69 +175,96 @@ func PictFromBytes(b []byte) (img image.Image, err error) {
 	// The next value is the header version. PICT version 2 has two variants that need to
 	// be handled for EV Nova. Annoyingly it seems to use both in its data files. Not sure
 	// how that happened?
-	headerVersion := parser.readDWord()
-	if (headerVersion & 0xFFFF0000) != 0xFFFE0000 { // Standard Header Version
-		// Determine the image resolution.
-		y2 := parser.readFixedPoint()
-		x2 := parser.readFixedPoint()
-		w2 := parser.readFixedPoint()
-		h2 := parser.readFixedPoint()
-
-		parser.xRatio = uint16(uint32(frame.x2-frame.x1) / (w2 - x2))
-		parser.yRatio = uint16(uint32(frame.y2-frame.y1) / (h2 - y2))
-	} else { // Extended Header Version
-		parser.pos += 2 * (2 * WordSize) // 2 * uint32
-		var rect = parser.readQDRect()
-		parser.xRatio = (frame.x2 - frame.x1) / (rect.x2 - rect.x1)
-		parser.yRatio = (frame.y2 - frame.y1) / (rect.y2 - rect.y1)
-	}
-
-	// Verify ratio is valid
-	if parser.xRatio <= 0 || parser.yRatio <= 0 {
-		return nil, errors.New(fmt.Sprintf("got an invalid ratio: [%v, %x]", parser.xRatio, parser.yRatio))
-	}
+	//
+	// Both variants then describe the resolution of the picture in 16 bytes, either as a fixed point
+	// bounding rect or as a resolution followed by the optimal source rect. Neither is needed, as bitmaps
+	// are scaled into their destination rects as they are drawn.
+	_ = parser.readDWord() // header version
+	parser.pos += 4 * 4
+
+	return parser.parsePictOpCodes(2)
+}
 
+// parsePictOpCodes runs the opcodes of a PICT until the end of picture. Version 1 opcodes are a single
+// byte and their data is unaligned, while version 2 opcodes are word aligned words. Both versions
+// share the same opcode numbering and data layouts.
+func (p *pictParser) parsePictOpCodes(version int) (image.Image, error) {
 	var op PictOpCode
 
-	for parser.pos < len(b) {
-		op = PictOpCode(parser.readOpCode())
+	for p.pos < p.d.GetLength() {
+		if version == 1 {
+			op = PictOpCode(p.readByte())
+		} else {
+			op = PictOpCode(p.readOpCode())
+		}
 
 		switch op {
 		case PictOpCodeClipRegion:
-			parser.readRegionWithRect()
+			p.clip = p.readRegion().translate(p.origin.Mul(-1))
+		case PictOpCodeBitsRect, PictOpCodeBitsRgn, PictOpCodePackBitsRect, PictOpCodePackBitsRgn:
+			err := p.parseBitsRect(op == PictOpCodePackBitsRect || op == PictOpCodePackBitsRgn, op == PictOpCodeBitsRgn || op == PictOpCodePackBitsRgn)
+			if err != nil {
+				return nil, err
+			}
 		case PictOpCodeDirectBitsRect:
-			img, err = parser.parseDirectBitsRect()
+			err := p.parseDirectBitsRect()
 			if err != nil {
 				return nil, err
 			}
+		case PictOpCodeCompressedQuickTime:
+			err := p.parseCompressedQuickTime()
+			if err != nil {
+				return nil, err
+			}
+		case PictOpCodeShortComment:
+			p.pos += WordSize // kind
 		case PictOpCodeLongComment:
-			parser.parseLongComment()
+			p.parseLongComment()
 		case PictOpCodeEof:
-			return img, nil
+			return p.canvas, nil
 		case PictOpCodeNop:
 		case PictOpCodeExtHeader:
 		case PictOpCodeDefHiLite:
 		default:
-			return nil, errors.New(fmt.Sprintf("encountered an unhandled opcode: [%04x]", op))
+			handled, err := p.parseQuickDrawOpCode(op)
+			if err != nil {
+				return nil, err
+			}
+			if !handled && !p.skipReservedOpCode(op) {
+				return nil, errors.New(fmt.Sprintf("encountered an unhandled opcode: [%04x]", op))
+			}
 		}
 	}
 
-	return img, nil
+	return p.canvas, nil
 }
 
-func (p *dataStructureParse) readRegionWithRect() regionRect {
-	var size = p.readWord()
-	var regionRect = regionRect{
-		x:      p.readWord() / p.xRatio,
-		y:      p.readWord() / p.yRatio,
-		width:  p.readWord() / p.xRatio,
-		height: p.readWord() / p.yRatio,
+// drawBits copies the source rect of a decoded bitmap into the destination rect of the canvas,
+// scaling it when the two rects differ in size. Pixels outside of the optional mask region, given in
+// destination coordinates, are left untouched.
+func (p *pictParser) drawBits(src image.Image, sourceRect image.Rectangle, destinationRect image.Rectangle, maskRgn *Region) {
+	var (
+		sw = sourceRect.Dx()
+		sh = sourceRect.Dy()
+		dw = destinationRect.Dx()
+		dh = destinationRect.Dy()
+	)
+	if sw <= 0 || sh <= 0 || dw <= 0 || dh <= 0 {
+		return
+	}
+
+	var area = destinationRect.Intersect(p.canvas.Bounds())
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		sy := sourceRect.Min.Y + (y-destinationRect.Min.Y)*sh/dh
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if maskRgn != nil && !maskRgn.Contains(x, y) || !p.clipped(x, y) {
+				continue
+			}
+
+			sx := sourceRect.Min.X + (x-destinationRect.Min.X)*sw/dw
+			p.canvas.Set(x, y, src.At(sx, sy))
+		}
 	}
-	regionRect.width -= regionRect.x
-	regionRect.height -= regionRect.y
-	var points = (size - 10) / 4
-	p.pos += int(2 * 2 * points)
-	return regionRect
 }
 
 func (p *dataStructureParse) parseLongComment() {
@@ -164,23 +274,31 @@ func (p *dataStructureParse) parseLongComment() {
 }
 
 func (p *dataStructureParse) packBitsDecode(valueSize int, data *DataView) ([]uint8, error) {
+	result, _, err := p.packBitsDecodeLimit(valueSize, data, 0)
+	return result, err
+}
+
+// packBitsDecodeLimit expands PackBits data, stopping once at least limit bytes have been produced when
+// limit is positive. It also returns the number of packed bytes consumed.
+func (p *dataStructureParse) packBitsDecodeLimit(valueSize int, data *DataView, limit int) ([]uint8, int, error) {
 	// valueSize is in bytes, byteLength is how many bytes to read
 	var result []uint8
 	var pos = 0
 	var length = data.GetLength()
 	if valueSize > 4 {
-		return nil, errors.New(fmt.Sprintf("valueSize too large. Must be <= 4 but got %v", valueSize))
+		return nil, 0, errors.New(fmt.Sprintf("valueSize too large. Must be <= 4 but got %v", valueSize))
 	}
 
 	var run int
-	for pos < length {
+	for pos < length && (limit <= 0 || len(result) < limit) {
 		var count = data.GetUint8(pos)
 		pos++
 
-		//fmt.Printf("count: %v\n", count)
-
 		if count < 128 {
 			run = int(1+count) * valueSize
+			if pos+run > length {
+				return nil, 0, errors.New("PackBits literal run overflows its data")
+			}
 			for i := 0; i < run; i++ {
 				result = append(result, data.GetUint8(pos+i))
 			}
@@ -188,6 +306,9 @@ func (p *dataStructureParse) packBitsDecode(valueSize int, data *DataView) ([]ui
 		} else {
 			// Expand the repeat compression
 			run = 256 - int(count)
+			if pos+valueSize > length {
+				return nil, 0, errors.New("PackBits repeated run overflows its data")
+			}
 			var val []uint8
 			for i := 0; i < valueSize; i++ {
 				val = append(val, data.GetUint8(pos+i))
@@ -199,10 +320,115 @@ func (p *dataStructureParse) packBitsDecode(valueSize int, data *DataView) ([]ui
 		}
 	}
 
-	return result, nil
+	return result, pos, nil
 }
 
-func (p *dataStructureParse) parseDirectBitsRect() (image.Image, error) {
+// readPackedRows reads height scan lines of rowBytes bytes each. When packed, rows of 8 or more bytes
+// are PackBits compressed and prefixed with their packed length.
+func (p *dataStructureParse) readPackedRows(rowBytes uint16, height uint16, packed bool) ([][]uint8, error) {
+	var rows = make([][]uint8, height)
+
+	for scanline := range rows {
+		if !packed || rowBytes < 8 { // No PackBits Compression
+			rows[scanline] = p.readDataUint8(int(rowBytes))
+			continue
+		}
+
+		var packedBytesCount uint16
+		if rowBytes > 250 {
+			packedBytesCount = p.readWord()
+		} else {
+			packedBytesCount = uint16(p.readByte())
+		}
+
+		var decodedScanLine, err = p.packBitsDecode(1, p.readData(int(packedBytesCount)))
+		if err != nil {
+			return nil, err
+		}
+		if len(decodedScanLine) < int(rowBytes) {
+			return nil, errors.New(fmt.Sprintf("scan line %v unpacked to %v bytes, expected %v", scanline, len(decodedScanLine), rowBytes))
+		}
+
+		rows[scanline] = decodedScanLine
+	}
+
+	return rows, nil
+}
+
+// parseBitsRect decodes the BitsRect family of opcodes: an indexed PixMap, or a 1-bit BitMap when the
+// high bit of rowBytes is clear. Unlike DirectBitsRect there is no base address, and a PixMap is
+// followed by its color table. The *Rgn variants carry a mask region in destination coordinates after
+// the transfer mode, and pixels falling outside of it are left transparent.
+func (p *pictParser) parseBitsRect(packed bool, masked bool) error {
+	var (
+		rowBytes = p.readWord()
+		px       pixMap
+		ct       colorTable
+	)
+
+	if rowBytes&0x8000 != 0 {
+		px = p.parsePixMapFields(rowBytes)
+		ct = p.parseColorTable()
+	} else {
+		// A plain BitMap is always black on white.
+		px = pixMap{
+			rowBytes:  rowBytes,
+			bounds:    p.readWHRect(),
+			pixelSize: 1,
+		}
+		ct = colorTable{
+			size: 2,
+			data: []colorRow{
+				{value: 0, r: 0xFFFF, g: 0xFFFF, b: 0xFFFF},
+				{value: 1, r: 0x0000, g: 0x0000, b: 0x0000},
+			},
+		}
+	}
+
+	var sourceRect = p.readWHRect()
+	var destinationRect = p.readWHRect()
+	var _ = p.readWord() // transfer mode
+
+	var maskRgn *Region
+	if masked {
+		maskRgn = p.readRegion().translate(p.origin.Mul(-1))
+	}
+
+	rows, err := p.readPackedRows(px.rowBytes, px.bounds.height, packed)
+	if err != nil {
+		return err
+	}
+
+	img, err := decodeIndexedRows(px, ct, rows)
+	if err != nil {
+		return err
+	}
+
+	p.drawBits(img, sourceRect.rectangle(), destinationRect.rectangle().Sub(p.origin), maskRgn)
+	return nil
+}
+
+// decodeIndexedRows converts rows of indexed pixels into an image covering the bounds of the PixMap.
+// Pixel values missing from the color table are left transparent.
+func decodeIndexedRows(px pixMap, ct colorTable, rows [][]uint8) (*image.NRGBA, error) {
+	img := image.NewNRGBA(px.bounds.rectangle())
+	for y := 0; y < int(px.bounds.height); y++ {
+		for x := 0; x < int(px.bounds.width); x++ {
+			col, err := readIndexedPixel(rows[y], uint32(x), px.pixelSize)
+			if err != nil {
+				return nil, err
+			}
+
+			if c, ok := ct.lookup(col); ok {
+				img.SetNRGBA(img.Rect.Min.X+x, img.Rect.Min.Y+y, c)
+			}
+		}
+	}
+
+	return img, nil
+}
+
+func (p *pictParser) parseDirectBitsRect() error {
 	px := p.parsePixMap()
 	sourceRect := p.readWHRect()
 	destinationRect := p.readWHRect()
@@ -222,11 +448,11 @@ func (p *dataStructureParse) parseDirectBitsRect() (image.Image, error) {
 	} else if px.packType == 4 {
 		raw = make([]uint8, int(math.Floor(float64(int32(px.cmpCount)*int32(px.rowBytes))/4.0)))
 	} else {
-		return nil, errors.New(fmt.Sprintf("unsupported pack type: %v", px.packType))
+		return errors.New(fmt.Sprintf("unsupported pack type: %v", px.packType))
 	}
 
-	pxShortArray = make([]uint16, int32(sourceRect.height)*(int32(px.rowBytes)+1))
-	pxArray = make([]uint32, int(math.Floor(float64(int32(sourceRect.height)*(int32(px.rowBytes)+3))/4.0)))
+	pxShortArray = make([]uint16, int32(px.bounds.height)*(int32(px.rowBytes)+1))
+	pxArray = make([]uint32, int(math.Floor(float64(int32(px.bounds.height)*(int32(px.rowBytes)+3))/4.0)))
 
 	var (
 		pxBufOffset      = uint32(0)
@@ -234,15 +460,28 @@ func (p *dataStructureParse) parseDirectBitsRect() (image.Image, error) {
 	)
 
 	var err error
-	for scanline := uint32(0); scanline < uint32(sourceRect.height); scanline++ {
+	for scanline := uint32(0); scanline < uint32(px.bounds.height); scanline++ {
 		// Narrow pictures don't use the pack bits compression. Not certain what the deciding factor
 		// for such a thing is, but low numbers of rowBytes seem to be the cause. Setting this to the
 		// highest value found that doesn't have compression
 		if px.rowBytes < 8 { // No PackBits Compression
 			// gets px.rowBytes number of bytes from d
-			// Then, puts sourceRect.width * 2 of them in 'raw'
+			// Then, puts px.bounds.width * 2 of them in 'raw'
 			var data = p.readDataUint8(int(px.rowBytes))
-			raw = data[0 : sourceRect.width*2]
+			if px.packType == 3 {
+				raw = data[0 : px.bounds.width*2]
+			} else {
+				// Unpacked 32-bit pixels are interleaved as alpha (or padding), red, green and
+				// blue. Split them into the component planes used by packed scan lines.
+				var planes = make([]uint8, uint32(px.cmpCount)*uint32(px.bounds.width))
+				var skip = 4 - uint32(px.cmpCount)
+				for i := uint32(0); i < uint32(px.bounds.width); i++ {
+					for c := uint32(0); c < uint32(px.cmpCount); c++ {
+						planes[c*uint32(px.bounds.width)+i] = data[4*i+skip+c]
+					}
+				}
+				raw = planes
+			}
 		} else { // Pack Bits Compression
 			if px.rowBytes > 250 {
 				packedBytesCount = p.readWord()
@@ -255,49 +494,47 @@ func (p *dataStructureParse) parseDirectBitsRect() (image.Image, error) {
 			if px.packType == 3 {
 				decodedScanLine, err = p.packBitsDecode(2, encodedScanLine)
 				if err != nil {
-					return nil, err
+					return err
 				}
 			} else {
 				decodedScanLine, err = p.packBitsDecode(1, encodedScanLine)
 				if err != nil {
-					return nil, err
+					return err
 				}
 			}
-			raw = decodedScanLine[0 : sourceRect.width*2]
+			if px.packType == 3 {
+				raw = decodedScanLine[0 : px.bounds.width*2]
+			} else {
+				// Each component is packed as its own plane.
+				raw = decodedScanLine[0 : px.cmpCount*px.bounds.width]
+			}
 		}
 
 		if px.packType == 3 {
 			// Store the decoded pixel data.
-			for i := uint32(0); i < uint32(sourceRect.width); i++ {
+			for i := uint32(0); i < uint32(px.bounds.width); i++ {
 				pxShortArray[pxBufOffset+i] = ((0xFF & uint16(raw[2*i])) << 8) | (0xFF & uint16(raw[2*i+1]))
 			}
 		} else {
 			if px.cmpCount == 3 {
 				// RGB Data
-				for i := uint32(0); i < uint32(sourceRect.width); i++ {
+				for i := uint32(0); i < uint32(px.bounds.width); i++ {
 					a := uint32(0xFF000000)
 					r := (uint32(raw[i]) & 0xFF) << 16
 					g := (uint32(raw[uint32(px.bounds.width)+i]) & 0xFF) << 8
-
-					// TODO: Determine why neither of the other solutions require this.
-					var b uint32
-					if 2*uint32(px.bounds.width)+i >= uint32(len(raw)) {
-						b = 0
-					} else {
-						b = uint32(raw[2*uint32(px.bounds.width)+i]) & 0xFF
-					}
+					b := uint32(raw[2*uint32(px.bounds.width)+i]) & 0xFF
 
 					pxArray[pxBufOffset+i] = a | r | g | b
 				}
 			} else {
 				// ARGB Data
-				for i := uint32(0); i < uint32(sourceRect.width); i++ {
+				for i := uint32(0); i < uint32(px.bounds.width); i++ {
 					pxArray[pxBufOffset+i] = (uint32(raw[i])&0xFF)<<24 | (uint32(raw[uint32(px.bounds.width)+i])&0xFF)<<16 | (uint32(raw[2*uint32(px.bounds.width)+i])&0xFF)<<8 | (uint32(raw[3*uint32(px.bounds.width)+i]) & 0xFF)
 				}
 			}
 		}
 
-		pxBufOffset += uint32(sourceRect.width)
+		pxBufOffset += uint32(px.bounds.width)
 	}
 
 	// Finally we need to unpack all of the pixel data. This is due to the pixels being
@@ -305,7 +542,7 @@ func (p *dataStructureParse) parseDirectBitsRect() (image.Image, error) {
 	// parsing this type of encoding so we need to convert it to a more modern
 	// representation, such as RGBA 8888
 	var (
-		sourceLength = uint32(destinationRect.width) * uint32(destinationRect.height)
+		sourceLength = uint32(px.bounds.width) * uint32(px.bounds.height)
 		rgbCount     = sourceLength * 4
 		rgbRaw       = make([]uint8, rgbCount)
 	)
@@ -336,11 +573,11 @@ func (p *dataStructureParse) parseDirectBitsRect() (image.Image, error) {
 		}
 	}
 
-	img := image.NewNRGBA(image.Rect(int(px.bounds.x), int(px.bounds.y), int(px.bounds.width), int(px.bounds.height)))
+	img := image.NewNRGBA(px.bounds.rectangle())
 	for x := 0; x < int(px.bounds.width); x++ {
 		for y := 0; y < int(px.bounds.height); y++ {
 			idx := (int(px.bounds.width)*y + x) << 2
-			img.Set(x, y, color.RGBA{
+			img.SetNRGBA(img.Rect.Min.X+x, img.Rect.Min.Y+y, color.NRGBA{
 				R: rgbRaw[idx+0],
 				G: rgbRaw[idx+1],
 				B: rgbRaw[idx+2],
@@ -349,5 +586,6 @@ func (p *dataStructureParse) parseDirectBitsRect() (image.Image, error) {
 		}
 	}
 
-	return img, nil
+	p.drawBits(img, sourceRect.rectangle(), destinationRect.rectangle().Sub(p.origin), nil)
+	return nil
 }
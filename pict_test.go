@@ -1,7 +1,11 @@
 package gomacimage
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"image"
+	"image/color"
 	"image/png"
 	"io/ioutil"
 	"os"
@@ -36,6 +40,7 @@ func TestPictFromBytes(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
@@ -73,3 +78,294 @@ func TestPictFromBytes(t *testing.T) {
 		})
 	}
 }
+
+// testPict wraps the given opcode stream in a version 2 PICT with an extended header.
+func testPict(frame image.Rectangle, ops ...[]byte) []byte {
+	var b bytes.Buffer
+	w := func(v ...interface{}) {
+		for _, x := range v {
+			_ = binary.Write(&b, binary.BigEndian, x)
+		}
+	}
+	r := func(r image.Rectangle) {
+		w(int16(r.Min.Y), int16(r.Min.X), int16(r.Max.Y), int16(r.Max.X))
+	}
+
+	w(uint16(0))
+	r(frame)
+	w(uint32(0x001102ff), uint16(PictOpCodeExtHeader), uint32(0xFFFE0000), uint32(0x00480000), uint32(0x00480000))
+	r(frame)
+	w(uint32(0))
+	for _, op := range ops {
+		b.Write(op)
+		if b.Len()%2 == 1 {
+			b.WriteByte(0)
+		}
+	}
+	w(uint16(PictOpCodeEof))
+
+	return b.Bytes()
+}
+
+// testOp serializes an opcode and its big endian operands.
+func testOp(op PictOpCode, operands ...interface{}) []byte {
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.BigEndian, op)
+	for _, x := range operands {
+		if r, ok := x.(image.Rectangle); ok {
+			x = []int16{int16(r.Min.Y), int16(r.Min.X), int16(r.Max.Y), int16(r.Max.X)}
+		}
+		_ = binary.Write(&b, binary.BigEndian, x)
+	}
+	return b.Bytes()
+}
+
+// testPackBitsLiteral packs a row using only literal runs.
+func testPackBitsLiteral(row []byte) []byte {
+	var out []byte
+	for len(row) > 0 {
+		n := len(row)
+		if n > 128 {
+			n = 128
+		}
+		out = append(out, uint8(n-1))
+		out = append(out, row[:n]...)
+		row = row[n:]
+	}
+	return append([]byte{uint8(len(out))}, out...)
+}
+
+// testPackBitsRectOp builds an 8-bit PackBitsRect whose color table maps 1 to red and 2 to blue.
+func testPackBitsRectOp(bounds, src, dst image.Rectangle, rows [][]byte) []byte {
+	var pixels []byte
+	for _, row := range rows {
+		pixels = append(pixels, testPackBitsLiteral(row)...)
+	}
+
+	return testOp(PictOpCodePackBitsRect,
+		uint16(0x8000|bounds.Dx()), bounds,
+		uint16(0), uint16(0), uint32(0), uint32(0x00480000), uint32(0x00480000),
+		uint16(0), uint16(8), uint16(1), uint16(8), uint32(0), uint32(0), uint32(0),
+		uint32(0), uint16(0), uint16(1),
+		[]uint16{1, 0xFFFF, 0, 0}, []uint16{2, 0, 0, 0xFFFF},
+		src, dst, uint16(0), pixels)
+}
+
+func TestPictFromBytes_PackBitsRect(t *testing.T) {
+	red := color.NRGBA{R: 0xFF, A: 0xFF}
+	blue := color.NRGBA{B: 0xFF, A: 0xFF}
+	bounds := image.Rect(0, 0, 12, 2)
+
+	op := testPackBitsRectOp(bounds, bounds, bounds, [][]byte{
+		{0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02},
+		{0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01},
+	})
+
+	got, err := PictFromBytes(testPict(bounds, op))
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	want := image.NewNRGBA(bounds)
+	for x := 0; x < 12; x++ {
+		if x < 6 {
+			want.SetNRGBA(x, 0, red)
+			want.SetNRGBA(x, 1, blue)
+		} else {
+			want.SetNRGBA(x, 0, blue)
+			want.SetNRGBA(x, 1, red)
+		}
+	}
+
+	_, _, errs := fuzzyCompImage(got, want)
+	for _, err := range errs {
+		t.Errorf("fuzzyCompImage() error = %v", err)
+	}
+}
+
+func TestPictFromBytes_BitsRgn(t *testing.T) {
+	bounds := image.Rect(0, 0, 4, 2)
+
+	// A triangle of inversion points leaves the top right and bottom left corners outside of the mask.
+	op := testOp(PictOpCodeBitsRgn,
+		uint16(1), bounds,
+		bounds, bounds, uint16(0),
+		[]uint16{
+			32, 0, 0, 2, 4,
+			0, 0, 3, 0x7FFF,
+			1, 0, 1, 3, 4, 0x7FFF,
+			0x7FFF,
+		},
+		[]byte{0xA0, 0x50})
+
+	got, err := PictFromBytes(testPict(bounds, op))
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	black := color.NRGBA{A: 0xFF}
+	white := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	want := image.NewNRGBA(bounds)
+	want.SetNRGBA(0, 0, black)
+	want.SetNRGBA(1, 0, white)
+	want.SetNRGBA(2, 0, black)
+	want.SetNRGBA(1, 1, black)
+	want.SetNRGBA(2, 1, white)
+	want.SetNRGBA(3, 1, black)
+
+	_, _, errs := fuzzyCompImage(got, want)
+	for _, err := range errs {
+		t.Errorf("fuzzyCompImage() error = %v", err)
+	}
+}
+
+func TestPictFromBytes_Version1(t *testing.T) {
+	binaryData := []byte{
+		0x00, 0x00, // size
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x08, // frame
+		0x11, 0x01, // version
+		0xA0, 0x00, 0x82, // short comment
+		0x01, 0x00, 0x0A, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x08, // clip region
+		0x90, 0x00, 0x02, // BitsRect with two bytes per row
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x08, // bounds
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x08, // source
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x08, // destination
+		0x00, 0x00, // mode
+		0xF0, 0x00,
+		0x0F, 0x00,
+		0xFF, // end of picture
+	}
+
+	got, err := PictFromBytes(binaryData)
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	want := image.NewNRGBA(image.Rect(0, 0, 8, 2))
+	for x := 0; x < 8; x++ {
+		top, bottom := color.NRGBA{A: 0xFF}, color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+		if x >= 4 {
+			top, bottom = bottom, top
+		}
+		want.SetNRGBA(x, 0, top)
+		want.SetNRGBA(x, 1, bottom)
+	}
+
+	_, _, errs := fuzzyCompImage(got, want)
+	for _, err := range errs {
+		t.Errorf("fuzzyCompImage() error = %v", err)
+	}
+}
+
+func TestPictFromBytes_Composite(t *testing.T) {
+	frame := image.Rect(0, 0, 12, 4)
+	red := color.NRGBA{R: 0xFF, A: 0xFF}
+	blue := color.NRGBA{B: 0xFF, A: 0xFF}
+	black := color.NRGBA{A: 0xFF}
+	white := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+
+	// The top band is a straight copy of a red and blue pixmap, the bottom band a 6x1 bitmap scaled
+	// up to twice its size.
+	top := testPackBitsRectOp(image.Rect(0, 0, 12, 1), image.Rect(0, 0, 12, 1), image.Rect(0, 1, 12, 2), [][]byte{
+		{0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02},
+	})
+	bottom := testOp(PictOpCodeBitsRect,
+		uint16(1), image.Rect(0, 0, 6, 1),
+		image.Rect(0, 0, 6, 1), image.Rect(0, 2, 12, 4), uint16(0),
+		[]byte{0xA8})
+
+	got, err := PictFromBytes(testPict(frame, top, bottom))
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	if got.Bounds() != frame {
+		t.Errorf("PictFromBytes() bounds = %v, want %v", got.Bounds(), frame)
+	}
+
+	want := image.NewNRGBA(frame)
+	for x := 0; x < 12; x++ {
+		if x < 6 {
+			want.SetNRGBA(x, 1, red)
+		} else {
+			want.SetNRGBA(x, 1, blue)
+		}
+		if (x/2)%2 == 0 {
+			want.SetNRGBA(x, 2, black)
+			want.SetNRGBA(x, 3, black)
+		} else {
+			want.SetNRGBA(x, 2, white)
+			want.SetNRGBA(x, 3, white)
+		}
+	}
+
+	_, _, errs := fuzzyCompImage(got, want)
+	for _, err := range errs {
+		t.Errorf("fuzzyCompImage() error = %v", err)
+	}
+}
+
+func TestPictFromFile(t *testing.T) {
+	want, err := PictFromFile("test/fixtures/pict/ship.bin")
+	if err != nil {
+		t.Fatalf("PictFromFile() error = %v", err)
+	}
+
+	binaryData, err := ioutil.ReadFile("test/fixtures/pict/ship.bin")
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile() error = %v", err)
+	}
+
+	file, err := ioutil.TempFile("", "ship-*.pict")
+	if err != nil {
+		t.Fatalf("ioutil.TempFile() error = %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, _ = file.Write(make([]byte, 512))
+	_, _ = file.Write(binaryData)
+	_ = file.Close()
+
+	got, err := PictFromFile(file.Name())
+	if err != nil {
+		t.Fatalf("PictFromFile() error = %v", err)
+	}
+
+	_, _, errs := fuzzyCompImage(got, want)
+	for _, err := range errs {
+		t.Errorf("fuzzyCompImage() error = %v", err)
+	}
+}
+
+func TestPictFromReader(t *testing.T) {
+	version1 := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x11, 0x01,
+		0x30, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, // frameRect
+		0xFF,
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "version 1", data: version1},
+		{name: "version 1 with header", data: append(make([]byte, 512), version1...)},
+		{name: "version 2", data: testPict(image.Rect(0, 0, 1, 1), testOp(PictOpCodeFrameRect, image.Rect(0, 0, 1, 1)))},
+		{name: "version 2 with header", data: append(make([]byte, 512), testPict(image.Rect(0, 0, 1, 1), testOp(PictOpCodeFrameRect, image.Rect(0, 0, 1, 1)))...)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := PictFromReader(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("PictFromReader() error = %v", err)
+			}
+
+			if c := color.NRGBAModel.Convert(got.At(0, 0)); c != (color.NRGBA{A: 0xFF}) {
+				t.Errorf("PictFromReader() [At(0, 0)] got = %v, want black", c)
+			}
+		})
+	}
+}
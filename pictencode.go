@@ -0,0 +1,220 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// PictEncodeOptions are the encoding parameters for PictEncode.
+type PictEncodeOptions struct {
+	// Depth is the pixel depth of the DirectBitsRect, either 16 or 32. It defaults to 32. Images
+	// with transparency keep an alpha channel at 32 bits per pixel.
+	Depth int
+}
+
+// PictEncode writes img as a version 2 PICT made of a single DirectBitsRect. Pixels are stored as
+// RGB 555 with PackBits compression at 16 bits per pixel (pack type 3), or as PackBits compressed
+// component planes at 32 bits per pixel (pack type 4). The output is raw resource data, without the
+// 512 byte header of PICT files.
+func PictEncode(w io.Writer, img image.Image, opts *PictEncodeOptions) error {
+	var depth = 32
+	if opts != nil && opts.Depth != 0 {
+		depth = opts.Depth
+	}
+	if depth != 16 && depth != 32 {
+		return errors.New(fmt.Sprintf("unsupported PICT depth: %v", depth))
+	}
+
+	var bounds = img.Bounds()
+	if bounds.Empty() {
+		return errors.New("cannot encode an empty image as a PICT")
+	}
+	if bounds.Min.X < -0x8000 || bounds.Min.Y < -0x8000 || bounds.Max.X > 0x7FFF || bounds.Max.Y > 0x7FFF {
+		return errors.New("image bounds are too large for a PICT")
+	}
+
+	var (
+		width    = bounds.Dx()
+		cmpCount = 3
+		packType = uint16(4)
+		cmpSize  = uint16(8)
+		rowBytes = width * 4
+	)
+
+	if depth == 16 {
+		packType, cmpSize, rowBytes = 3, 5, width*2
+	} else if !isOpaque(img) {
+		cmpCount = 4
+	}
+
+	if rowBytes > 0x3FFF {
+		return errors.New("image is too wide for a PICT")
+	}
+
+	var out dataStructureWrite
+
+	// The size is filled in once the picture is complete.
+	out.writeWord(0)
+	out.writeQDRect(bounds)
+	out.writeDWord(0x001102ff)
+
+	out.writeOpCode(PictOpCodeExtHeader)
+	out.writeDWord(0xFFFE0000)
+	out.writeDWord(0x00480000) // 72 dpi
+	out.writeDWord(0x00480000)
+	out.writeQDRect(bounds)
+	out.writeDWord(0)
+
+	out.writeOpCode(PictOpCodeDefHiLite)
+
+	out.writeOpCode(PictOpCodeClipRegion)
+	out.writeWord(10)
+	out.writeQDRect(bounds)
+
+	out.writeOpCode(PictOpCodeDirectBitsRect)
+	out.writeDWord(0x000000FF) // base address
+	out.writeWord(0x8000 | uint16(rowBytes))
+	out.writeQDRect(bounds)
+	out.writeWord(0) // pmVersion
+	out.writeWord(packType)
+	out.writeDWord(0) // packSize
+	out.writeDWord(0x00480000)
+	out.writeDWord(0x00480000)
+	out.writeWord(16) // RGBDirect
+	out.writeWord(uint16(depth))
+	out.writeWord(uint16(cmpCount))
+	out.writeWord(cmpSize)
+	out.writeDWord(0) // planeBytes
+	out.writeDWord(0) // pmTable
+	out.writeDWord(0) // pmReserved
+	out.writeQDRect(bounds)
+	out.writeQDRect(bounds)
+	out.writeWord(0) // srcCopy
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		var row []uint8
+		if depth == 16 {
+			row = make([]uint8, 0, rowBytes)
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				px := uint16(c.R>>3)<<10 | uint16(c.G>>3)<<5 | uint16(c.B>>3)
+				row = append(row, uint8(px>>8), uint8(px))
+			}
+		} else if rowBytes < 8 {
+			// Narrow rows are left unpacked, with the pixels interleaved.
+			row = make([]uint8, 0, rowBytes)
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				row = append(row, c.A, c.R, c.G, c.B)
+			}
+		} else {
+			// Packed rows hold each component as its own plane, alpha first when present.
+			row = make([]uint8, cmpCount*width)
+			for i, x := 0, bounds.Min.X; x < bounds.Max.X; i, x = i+1, x+1 {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				planes := []uint8{c.A, c.R, c.G, c.B}[4-cmpCount:]
+				for plane, v := range planes {
+					row[plane*width+i] = v
+				}
+			}
+		}
+
+		if rowBytes < 8 {
+			out.writeData(row)
+			continue
+		}
+
+		var valueSize = 1
+		if packType == 3 {
+			valueSize = 2
+		}
+
+		var packed = packBitsEncode(valueSize, row)
+		if rowBytes > 250 {
+			out.writeWord(uint16(len(packed)))
+		} else {
+			if len(packed) > 0xFF {
+				return errors.New("packed scan line is too long for its byte count")
+			}
+			out.writeByte(uint8(len(packed)))
+		}
+		out.writeData(packed)
+	}
+
+	out.writeOpCode(PictOpCodeEof)
+
+	var b = out.b.Bytes()
+	b[0] = uint8(len(b) >> 8)
+	b[1] = uint8(len(b))
+
+	_, err := w.Write(b)
+	return err
+}
+
+// packBitsEncode compresses data made up of values of valueSize bytes with PackBits, producing the
+// runs that packBitsDecode expands. Runs of three or more equal values are repeated, anything else is
+// copied literally.
+func packBitsEncode(valueSize int, data []uint8) []uint8 {
+	var (
+		result []uint8
+		count  = len(data) / valueSize
+		value  = func(i int) []uint8 { return data[i*valueSize : (i+1)*valueSize] }
+		equal  = func(i, j int) bool {
+			for k := 0; k < valueSize; k++ {
+				if data[i*valueSize+k] != data[j*valueSize+k] {
+					return false
+				}
+			}
+			return true
+		}
+	)
+
+	for i := 0; i < count; {
+		var run = 1
+		for i+run < count && run < 128 && equal(i, i+run) {
+			run++
+		}
+
+		if run >= 3 {
+			result = append(result, uint8(257-run))
+			result = append(result, value(i)...)
+			i += run
+			continue
+		}
+
+		// Gather literal values until the next worthwhile run begins.
+		var literal = 0
+		for i+literal < count && literal < 128 {
+			if i+literal+2 < count && equal(i+literal, i+literal+1) && equal(i+literal, i+literal+2) {
+				break
+			}
+			literal++
+		}
+
+		result = append(result, uint8(literal-1))
+		result = append(result, data[i*valueSize:(i+literal)*valueSize]...)
+		i += literal
+	}
+
+	return result
+}
+
+// isOpaque reports whether every pixel of img is fully opaque.
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+
+	var b = img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xFFFF {
+				return false
+			}
+		}
+	}
+	return true
+}
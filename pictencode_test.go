@@ -0,0 +1,133 @@
+package gomacimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestPackBitsEncode(t *testing.T) {
+	tests := []struct {
+		name      string
+		valueSize int
+		data      []uint8
+	}{
+		{name: "empty", valueSize: 1, data: []uint8{}},
+		{name: "literal", valueSize: 1, data: []uint8{1, 2, 3, 4, 5}},
+		{name: "run", valueSize: 1, data: bytes.Repeat([]uint8{7}, 300)},
+		{name: "mixed", valueSize: 1, data: []uint8{1, 1, 2, 2, 2, 2, 3, 4, 4, 5, 5, 5}},
+		{name: "long literal", valueSize: 1, data: func() []uint8 {
+			d := make([]uint8, 400)
+			for i := range d {
+				d[i] = uint8(i)
+			}
+			return d
+		}()},
+		{name: "words", valueSize: 2, data: []uint8{0x12, 0x34, 0x12, 0x34, 0x12, 0x34, 0x56, 0x78, 0x12, 0x34}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packed := packBitsEncode(tt.valueSize, tt.data)
+
+			var parser dataStructureParse
+			got, err := parser.packBitsDecode(tt.valueSize, NewBigEndianDataView(packed))
+			if err != nil {
+				t.Fatalf("packBitsDecode() error = %v", err)
+			}
+			if len(got) != 0 || len(tt.data) != 0 {
+				if !reflect.DeepEqual(got, tt.data) {
+					t.Errorf("packBitsDecode(packBitsEncode()) = %v, want %v", got, tt.data)
+				}
+			}
+		})
+	}
+}
+
+func TestPictEncode(t *testing.T) {
+	gradient := func(r image.Rectangle, alpha bool) *image.NRGBA {
+		img := image.NewNRGBA(r)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				c := color.NRGBA{R: uint8(x * 8), G: uint8(y * 8), B: uint8((x + y) * 8), A: 0xFF}
+				if alpha && x%3 == 0 {
+					c.A = uint8(y * 16)
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+		return img
+	}
+
+	tests := []struct {
+		name  string
+		img   image.Image
+		depth int
+	}{
+		{name: "32-bit", img: gradient(image.Rect(0, 0, 20, 10), false), depth: 32},
+		{name: "32-bit with alpha", img: gradient(image.Rect(0, 0, 20, 10), true), depth: 32},
+		{name: "32-bit wide", img: gradient(image.Rect(0, 0, 70, 3), false), depth: 32},
+		{name: "32-bit narrow", img: gradient(image.Rect(0, 0, 1, 4), true), depth: 32},
+		{name: "16-bit", img: gradient(image.Rect(0, 0, 20, 10), false), depth: 16},
+		{name: "16-bit wide", img: gradient(image.Rect(0, 0, 130, 2), false), depth: 16},
+		{name: "16-bit offset", img: gradient(image.Rect(2, 3, 12, 8), false), depth: 16},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			if err := PictEncode(&b, tt.img, &PictEncodeOptions{Depth: tt.depth}); err != nil {
+				t.Fatalf("PictEncode() error = %v", err)
+			}
+
+			got, err := PictFromBytes(b.Bytes())
+			if err != nil {
+				t.Fatalf("PictFromBytes() error = %v", err)
+			}
+
+			if got.Bounds() != tt.img.Bounds() {
+				t.Errorf("PictFromBytes() bounds = %v, want %v", got.Bounds(), tt.img.Bounds())
+			}
+
+			_, _, errs := fuzzyCompImage(got, tt.img)
+			for _, err := range errs {
+				t.Errorf("fuzzyCompImage() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestPictEncode_Fixture(t *testing.T) {
+	binaryData, err := ioutil.ReadFile("test/fixtures/pict/landed.bin")
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile() error = %v", err)
+	}
+
+	want, err := PictFromBytes(binaryData)
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	// The fixture is already 16-bit, so encoding it at the same depth is lossless.
+	var b bytes.Buffer
+	if err := PictEncode(&b, want, &PictEncodeOptions{Depth: 16}); err != nil {
+		t.Fatalf("PictEncode() error = %v", err)
+	}
+
+	got, err := PictFromBytes(b.Bytes())
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	_, _, errs := fuzzyCompImage(got, want)
+	for _, err := range errs {
+		t.Errorf("fuzzyCompImage() error = %v", err)
+	}
+}
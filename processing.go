@@ -1,5 +1,13 @@
 package gomacimage
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
 const (
 	WordSize = 2
 )
@@ -11,11 +19,13 @@ type macRectangle struct {
 	x2 uint16
 }
 
+func (r macRectangle) rectangle() image.Rectangle {
+	return image.Rect(int(int16(r.x1)), int(int16(r.y1)), int(int16(r.x2)), int(int16(r.y2)))
+}
+
 type dataStructureParse struct {
-	d      *DataView
-	pos    int
-	xRatio uint16
-	yRatio uint16
+	d   *DataView
+	pos int
 }
 
 type regionRect struct {
@@ -25,6 +35,11 @@ type regionRect struct {
 	height uint16
 }
 
+func (r regionRect) rectangle() image.Rectangle {
+	var x, y = int(int16(r.x)), int(int16(r.y))
+	return image.Rect(x, y, x+int(int16(r.width)), y+int(int16(r.height)))
+}
+
 type pixMap struct {
 	baseAddress uint32
 	rowBytes    uint16
@@ -69,9 +84,17 @@ func (p *dataStructureParse) readData(len int) *DataView {
 }
 
 func (p *dataStructureParse) parsePixMap() pixMap {
+	var baseAddress = p.readDWord()
+	var px = p.parsePixMapFields(p.readWord())
+	px.baseAddress = baseAddress
+	return px
+}
+
+// parsePixMapFields reads the remainder of a PixMap following its rowBytes. PICT bitmap opcodes omit
+// the base address and need to inspect the high bit of rowBytes before deciding what follows.
+func (p *dataStructureParse) parsePixMapFields(rowBytes uint16) pixMap {
 	return pixMap{
-		baseAddress: p.readDWord(),
-		rowBytes:    p.readWord() & 0x7FFF,
+		rowBytes: rowBytes & 0x7FFF,
 
 		bounds: p.readWHRect(),
 
@@ -105,11 +128,112 @@ type colorRow struct {
 	r, g, b, value uint16
 }
 
+func (c colorRow) nrgba() color.NRGBA {
+	return color.NRGBA{
+		R: uint8(c.r >> 8),
+		G: uint8(c.g >> 8),
+		B: uint8(c.b >> 8),
+		A: 0xFF,
+	}
+}
+
+// isDevice reports whether the table is a device color table, whose entries are found by position
+// rather than by their value.
+func (ct colorTable) isDevice() bool {
+	return ct.flags&0x8000 != 0
+}
+
+// lookup finds the color table entry whose value matches the given pixel value. An empty table stands
+// for the standard color table its seed names.
+func (ct colorTable) lookup(value uint16) (color.NRGBA, bool) {
+	if ct.size == 0 {
+		if pal, ok := systemPaletteByID(ct.seed); ok && int(value) < len(pal) {
+			return color.NRGBAModel.Convert(pal[value]).(color.NRGBA), true
+		}
+		return color.NRGBA{}, false
+	}
+
+	if ct.isDevice() {
+		if value < ct.size {
+			return ct.data[value].nrgba(), true
+		}
+		return color.NRGBA{}, false
+	}
+
+	for i := uint16(0); i < ct.size; i++ {
+		if ct.data[i].value == value {
+			return ct.data[i].nrgba(), true
+		}
+	}
+	return color.NRGBA{}, false
+}
+
+// palette returns the color table as a palette indexed by pixel value. Values without an entry are
+// black.
+func (ct colorTable) palette() color.Palette {
+	if ct.size == 0 {
+		pal, _ := systemPaletteByID(ct.seed)
+		return append(color.Palette(nil), pal...)
+	}
+
+	var count = int(ct.size)
+	if !ct.isDevice() {
+		count = 0
+		for _, row := range ct.data {
+			if int(row.value) >= count {
+				count = int(row.value) + 1
+			}
+		}
+	}
+
+	var pal = make(color.Palette, count)
+	for i := range pal {
+		pal[i] = color.NRGBA{A: 0xFF}
+	}
+
+	for i, row := range ct.data {
+		if ct.isDevice() {
+			pal[i] = row.nrgba()
+		} else {
+			pal[row.value] = row.nrgba()
+		}
+	}
+
+	return pal
+}
+
+// readIndexedPixel returns the value of the pixel at idx within rows of packed 1, 2, 4 or 8 bit pixels.
+func readIndexedPixel(data []uint8, idx uint32, pixelSize uint16) (uint16, error) {
+	switch pixelSize {
+	case 1, 2, 4, 8:
+	default:
+		return 0, errors.New(fmt.Sprintf("unhandled pixel size: %v", pixelSize))
+	}
+
+	perByte := 8 / uint32(pixelSize)
+	shift := 8 - uint32(pixelSize)*(idx%perByte+1)
+	mask := uint16(1)<<pixelSize - 1
+
+	return (uint16(data[idx/perByte]) >> shift) & mask, nil
+}
+
+// readBit returns the bit of the pixel at x, y in 1-bit image data with rows of rowBytes bytes.
+func readBit(data []uint8, rowBytes uint16, x int, y int) uint8 {
+	idx := uint32(y)*uint32(rowBytes)*8 + uint32(x)
+	bit, _ := readIndexedPixel(data, idx, 1)
+	return uint8(bit)
+}
+
+// parseColorTable reads a ColorTable. Its ctSize holds the number of entries minus one, so a ctSize of
+// 0xFFFF leaves the table empty, standing for the standard color table whose ID is the seed.
 func (p *dataStructureParse) parseColorTable() colorTable {
 	ct := colorTable{
 		seed:  p.readDWord(),
 		flags: p.readWord(),
-		size:  p.readWord() + 1,
+	}
+
+	if size := p.readWord(); size != 0xFFFF {
+		ct.size = size + 1
 	}
 
 	ct.data = make([]colorRow, ct.size)
@@ -175,3 +299,40 @@ func (p *dataStructureParse) readOpCode() uint16 {
 	p.pos += p.pos % 2
 	return p.readWord()
 }
+
+// dataStructureWrite mirrors dataStructureParse, writing big endian data structures into a buffer.
+type dataStructureWrite struct {
+	b bytes.Buffer
+}
+
+func (w *dataStructureWrite) writeByte(b uint8) {
+	w.b.WriteByte(b)
+}
+
+func (w *dataStructureWrite) writeWord(word uint16) {
+	w.b.WriteByte(uint8(word >> 8))
+	w.b.WriteByte(uint8(word))
+}
+
+func (w *dataStructureWrite) writeDWord(word uint32) {
+	w.writeWord(uint16(word >> 16))
+	w.writeWord(uint16(word))
+}
+
+func (w *dataStructureWrite) writeData(d []byte) {
+	w.b.Write(d)
+}
+
+func (w *dataStructureWrite) writeQDRect(r image.Rectangle) {
+	w.writeWord(uint16(r.Min.Y))
+	w.writeWord(uint16(r.Min.X))
+	w.writeWord(uint16(r.Max.Y))
+	w.writeWord(uint16(r.Max.X))
+}
+
+func (w *dataStructureWrite) writeOpCode(op uint16) {
+	if w.b.Len()%2 == 1 {
+		w.writeByte(0)
+	}
+	w.writeWord(op)
+}
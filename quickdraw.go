@@ -0,0 +1,655 @@
+package gomacimage
+
+// See Inside Macintosh: Imaging With QuickDraw, Appendix A for the opcode table and the data each
+// opcode carries.
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// QuickDraw drawing opcodes. Each shape opcode listed here frames the shape. The four opcodes that
+// follow it paint, erase, invert and fill the same shape, and the "Same" variants reuse the geometry of
+// the previous shape of that kind.
+const (
+	PictOpCodeBkPat          PictOpCode = 0x0002
+	PictOpCodeTxFont                    = 0x0003
+	PictOpCodeTxFace                    = 0x0004
+	PictOpCodeTxMode                    = 0x0005
+	PictOpCodeSpExtra                   = 0x0006
+	PictOpCodePnSize                    = 0x0007
+	PictOpCodePnMode                    = 0x0008
+	PictOpCodePnPat                     = 0x0009
+	PictOpCodeFillPat                   = 0x000A
+	PictOpCodeOvSize                    = 0x000B
+	PictOpCodeOrigin                    = 0x000C
+	PictOpCodeTxSize                    = 0x000D
+	PictOpCodeFgColor                   = 0x000E
+	PictOpCodeBkColor                   = 0x000F
+	PictOpCodeTxRatio                   = 0x0010
+	PictOpCodeVersion                   = 0x0011
+	PictOpCodeBkPixPat                  = 0x0012
+	PictOpCodePnPixPat                  = 0x0013
+	PictOpCodeFillPixPat                = 0x0014
+	PictOpCodePnLocHFrac                = 0x0015
+	PictOpCodeChExtra                   = 0x0016
+	PictOpCodeRGBFgCol                  = 0x001A
+	PictOpCodeRGBBkCol                  = 0x001B
+	PictOpCodeHiliteMode                = 0x001C
+	PictOpCodeHiliteColor               = 0x001D
+	PictOpCodeOpColor                   = 0x001F
+	PictOpCodeLine                      = 0x0020
+	PictOpCodeLineFrom                  = 0x0021
+	PictOpCodeShortLine                 = 0x0022
+	PictOpCodeShortLineFrom             = 0x0023
+	PictOpCodeLongText                  = 0x0028
+	PictOpCodeDHText                    = 0x0029
+	PictOpCodeDVText                    = 0x002A
+	PictOpCodeDHDVText                  = 0x002B
+	PictOpCodeFrameRect                 = 0x0030
+	PictOpCodeFrameSameRect             = 0x0038
+	PictOpCodeFrameRRect                = 0x0040
+	PictOpCodeFrameSameRRect            = 0x0048
+	PictOpCodeFrameOval                 = 0x0050
+	PictOpCodeFrameSameOval             = 0x0058
+	PictOpCodeFrameArc                  = 0x0060
+	PictOpCodeFrameSameArc              = 0x0068
+	PictOpCodeFramePoly                 = 0x0070
+	PictOpCodeFrameSamePoly             = 0x0078
+	PictOpCodeFrameRgn                  = 0x0080
+	PictOpCodeFrameSameRgn              = 0x0088
+)
+
+// The drawing verbs, offset from the frame opcode of each shape.
+const (
+	qdVerbFrame = iota
+	qdVerbPaint
+	qdVerbErase
+	qdVerbInvert
+	qdVerbFill
+)
+
+// Pattern transfer modes. The source modes 0 to 7 behave the same way when drawing shapes.
+const (
+	qdModePatCopy = 8
+	qdModePatOr   = 9
+	qdModePatXor  = 10
+	qdModePatBic  = 11
+)
+
+// pattern is an 8x8 QuickDraw pattern. Set bits draw in the foreground color and clear bits in the
+// background color, unless the pattern is a pixel pattern with its own tile.
+type pattern struct {
+	bits [8]uint8
+	tile image.Image
+}
+
+var (
+	patternBlack = pattern{bits: [8]uint8{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}}
+	patternWhite = pattern{}
+)
+
+func (pat pattern) set(x, y int) bool {
+	return pat.bits[y&7]&(0x80>>uint(x&7)) != 0
+}
+
+// at returns the color of a pixel pattern's tile repeated across the canvas.
+func (pat pattern) at(x, y int) color.Color {
+	var b = pat.tile.Bounds()
+	var tx, ty = (x - b.Min.X) % b.Dx(), (y - b.Min.Y) % b.Dy()
+	if tx < 0 {
+		tx += b.Dx()
+	}
+	if ty < 0 {
+		ty += b.Dy()
+	}
+	return pat.tile.At(b.Min.X+tx, b.Min.Y+ty)
+}
+
+// quickDrawState is the graphics port state that drawing opcodes read and modify.
+type quickDrawState struct {
+	penLocation image.Point
+	penSize     image.Point
+	penMode     uint16
+	penPattern  pattern
+	fillPattern pattern
+	backPattern pattern
+	foreground  color.NRGBA
+	background  color.NRGBA
+	ovalSize    image.Point
+	origin      image.Point
+
+	lastRect     image.Rectangle
+	lastPoly     []image.Point
+	lastPolyRect image.Rectangle
+	lastRgn      *Region
+}
+
+func newQuickDrawState() quickDrawState {
+	return quickDrawState{
+		penSize:     image.Pt(1, 1),
+		penMode:     qdModePatCopy,
+		penPattern:  patternBlack,
+		fillPattern: patternBlack,
+		backPattern: patternWhite,
+		foreground:  color.NRGBA{A: 0xFF},
+		background:  color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF},
+	}
+}
+
+// oldStyleColors maps the eight colors of the original QuickDraw color model.
+var oldStyleColors = map[uint32]color.NRGBA{
+	30:  {R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}, // whiteColor
+	33:  {A: 0xFF},                            // blackColor
+	69:  {R: 0xFC, G: 0xF3, B: 0x05, A: 0xFF}, // yellowColor
+	137: {R: 0xF2, G: 0x08, B: 0x84, A: 0xFF}, // magentaColor
+	205: {R: 0xDD, G: 0x08, B: 0x06, A: 0xFF}, // redColor
+	273: {R: 0x02, G: 0xAB, B: 0xEA, A: 0xFF}, // cyanColor
+	341: {R: 0x00, G: 0x80, B: 0x11, A: 0xFF}, // greenColor
+	409: {R: 0x00, G: 0x00, B: 0xD4, A: 0xFF}, // blueColor
+}
+
+func (p *dataStructureParse) readQDPoint() image.Point {
+	var y = int16(p.readWord())
+	var x = int16(p.readWord())
+	return image.Pt(int(x), int(y))
+}
+
+func (p *dataStructureParse) readRGBColor() color.NRGBA {
+	return colorRow{r: p.readWord(), g: p.readWord(), b: p.readWord()}.nrgba()
+}
+
+func (p *dataStructureParse) readPattern() pattern {
+	var pat pattern
+	copy(pat.bits[:], p.readDataUint8(8))
+	return pat
+}
+
+// readPolygon reads a polygon's size, bounding box and the points that follow.
+func (p *dataStructureParse) readPolygon() ([]image.Point, image.Rectangle) {
+	var size = int(p.readWord())
+	var bounds = p.readQDRect().rectangle()
+	var points = make([]image.Point, 0, (size-10)/4)
+	for i := 0; i < (size-10)/4; i++ {
+		points = append(points, p.readQDPoint())
+	}
+	return points, bounds
+}
+
+// readPixPat reads a pixel pattern. Dither patterns carry a single RGB color while full pixel patterns
+// carry a PixMap, laid out as in PackBitsRect, along with its color table and pixel data.
+func (p *pictParser) readPixPat() (pattern, error) {
+	var patType = p.readWord()
+	var pat = p.readPattern()
+
+	if patType == 2 {
+		pat.tile = image.NewUniform(p.readRGBColor())
+		return pat, nil
+	}
+
+	var px = p.parsePixMapFields(p.readWord())
+	var ct = p.parseColorTable()
+	rows, err := p.readPackedRows(px.rowBytes, px.bounds.height, true)
+	if err != nil {
+		return pat, err
+	}
+
+	pat.tile, err = decodeIndexedRows(px, ct, rows)
+	return pat, err
+}
+
+// parseQuickDrawOpCode plays back a single drawing or port state opcode. It reports false when the
+// opcode is not one it knows.
+func (p *pictParser) parseQuickDrawOpCode(op PictOpCode) (bool, error) {
+	switch op {
+	case PictOpCodeBkPat:
+		p.backPattern = p.readPattern()
+	case PictOpCodePnPat:
+		p.penPattern = p.readPattern()
+	case PictOpCodeFillPat:
+		p.fillPattern = p.readPattern()
+	case PictOpCodeBkPixPat, PictOpCodePnPixPat, PictOpCodeFillPixPat:
+		pat, err := p.readPixPat()
+		if err != nil {
+			return true, err
+		}
+		switch op {
+		case PictOpCodeBkPixPat:
+			p.backPattern = pat
+		case PictOpCodePnPixPat:
+			p.penPattern = pat
+		default:
+			p.fillPattern = pat
+		}
+	case PictOpCodePnSize:
+		p.penSize = p.readQDPoint()
+	case PictOpCodePnMode:
+		p.penMode = p.readWord()
+	case PictOpCodeOvSize:
+		p.ovalSize = p.readQDPoint()
+	case PictOpCodeOrigin:
+		var dh = int16(p.readWord())
+		var dv = int16(p.readWord())
+		p.origin = p.origin.Add(image.Pt(int(dh), int(dv)))
+	case PictOpCodeFgColor, PictOpCodeBkColor:
+		var c, ok = oldStyleColors[p.readDWord()]
+		if !ok {
+			break
+		}
+		if op == PictOpCodeFgColor {
+			p.foreground = c
+		} else {
+			p.background = c
+		}
+	case PictOpCodeRGBFgCol:
+		p.foreground = p.readRGBColor()
+	case PictOpCodeRGBBkCol:
+		p.background = p.readRGBColor()
+	case PictOpCodeHiliteColor, PictOpCodeOpColor:
+		p.pos += 6
+	case PictOpCodeTxFace:
+		p.pos++
+	case PictOpCodeTxFont, PictOpCodeTxMode, PictOpCodeTxSize, PictOpCodePnLocHFrac, PictOpCodeChExtra:
+		p.pos += WordSize
+	case PictOpCodeSpExtra:
+		p.pos += 4
+	case PictOpCodeTxRatio:
+		p.pos += 8
+	case PictOpCodeHiliteMode, PictOpCodeVersion:
+
+	// Text needs the fonts it was drawn with, so it is skipped.
+	case PictOpCodeLongText:
+		p.pos += 4
+		p.pos += int(p.readByte())
+	case PictOpCodeDHText, PictOpCodeDVText:
+		p.pos++
+		p.pos += int(p.readByte())
+	case PictOpCodeDHDVText:
+		p.pos += 2
+		p.pos += int(p.readByte())
+
+	case PictOpCodeLine:
+		p.penLocation = p.readQDPoint()
+		p.lineTo(p.readQDPoint())
+	case PictOpCodeLineFrom:
+		p.lineTo(p.readQDPoint())
+	case PictOpCodeShortLine:
+		p.penLocation = p.readQDPoint()
+		fallthrough
+	case PictOpCodeShortLineFrom:
+		var dh = int8(p.readByte())
+		var dv = int8(p.readByte())
+		p.lineTo(p.penLocation.Add(image.Pt(int(dh), int(dv))))
+
+	default:
+		if op < PictOpCodeFrameRect || op >= PictOpCodeFrameSameRgn+8 || op&0x07 > qdVerbFill {
+			return false, nil
+		}
+		return true, p.parseShapeOpCode(op)
+	}
+
+	return true, nil
+}
+
+// parseShapeOpCode reads the geometry of a rect, round rect, oval, arc, polygon or region opcode and
+// draws it with the verb encoded in the low bits of the opcode.
+func (p *pictParser) parseShapeOpCode(op PictOpCode) error {
+	var (
+		verb   = int(op & 0x07)
+		family = op &^ 0x07
+	)
+
+	switch family {
+	case PictOpCodeFrameRect, PictOpCodeFrameRRect, PictOpCodeFrameOval, PictOpCodeFrameArc:
+		p.lastRect = p.readQDRect().rectangle()
+	case PictOpCodeFramePoly:
+		p.lastPoly, p.lastPolyRect = p.readPolygon()
+	case PictOpCodeFrameRgn:
+		p.lastRgn = p.readRegion()
+	}
+
+	var r = p.lastRect.Sub(p.origin)
+
+	switch family {
+	case PictOpCodeFrameRect, PictOpCodeFrameSameRect:
+		p.drawShape(verb, r, func(r image.Rectangle, x, y int) bool {
+			return image.Pt(x, y).In(r)
+		})
+	case PictOpCodeFrameRRect, PictOpCodeFrameSameRRect:
+		var oval = p.ovalSize
+		p.drawShape(verb, r, func(inner image.Rectangle, x, y int) bool {
+			// The corner ovals of the inner edge of a frame shrink along with the rectangle.
+			var shrink = r.Dx() - inner.Dx()
+			return insideRoundRect(inner, oval.Sub(image.Pt(shrink, r.Dy()-inner.Dy())), x, y)
+		})
+	case PictOpCodeFrameOval, PictOpCodeFrameSameOval:
+		p.drawShape(verb, r, insideOval)
+	case PictOpCodeFrameArc, PictOpCodeFrameSameArc:
+		var start = int(int16(p.readWord()))
+		var arc = int(int16(p.readWord()))
+		p.drawShape(verb, r, func(inner image.Rectangle, x, y int) bool {
+			return insideOval(inner, x, y) && insideArcAngle(r, start, arc, x, y)
+		})
+	case PictOpCodeFramePoly, PictOpCodeFrameSamePoly:
+		var points = make([]image.Point, len(p.lastPoly))
+		for i, pt := range p.lastPoly {
+			points[i] = pt.Sub(p.origin)
+		}
+		if verb == qdVerbFrame {
+			for i := 1; i < len(points); i++ {
+				p.penLocation = points[i-1]
+				p.lineTo(points[i])
+			}
+			return nil
+		}
+		p.applyVerb(verb, p.lastPolyRect.Sub(p.origin), func(x, y int) bool {
+			return insidePolygon(points, x, y)
+		})
+	case PictOpCodeFrameRgn, PictOpCodeFrameSameRgn:
+		if p.lastRgn == nil {
+			return errors.New("same region opcode encountered before any region")
+		}
+		var rgn = p.lastRgn.translate(p.origin.Mul(-1))
+		if verb == qdVerbFrame {
+			var w, h = p.penSize.X, p.penSize.Y
+			p.applyVerb(verb, rgn.bounds, func(x, y int) bool {
+				return rgn.Contains(x, y) && !(rgn.Contains(x-w, y) && rgn.Contains(x+w, y) && rgn.Contains(x, y-h) && rgn.Contains(x, y+h))
+			})
+			return nil
+		}
+		p.applyVerb(verb, rgn.bounds, rgn.Contains)
+	default:
+		return errors.New(fmt.Sprintf("unhandled shape opcode: [%04x]", uint16(op)))
+	}
+
+	return nil
+}
+
+// drawShape draws a shape bounded by r. Framing draws the band between the shape and the same shape
+// inset by the pen size.
+func (p *pictParser) drawShape(verb int, r image.Rectangle, inside func(r image.Rectangle, x, y int) bool) {
+	if verb != qdVerbFrame {
+		p.applyVerb(verb, r, func(x, y int) bool {
+			return inside(r, x, y)
+		})
+		return
+	}
+
+	if p.penSize.X <= 0 || p.penSize.Y <= 0 {
+		return
+	}
+
+	var inner = image.Rectangle{
+		Min: r.Min.Add(p.penSize),
+		Max: r.Max.Sub(p.penSize),
+	}
+	p.applyVerb(verb, r, func(x, y int) bool {
+		return inside(r, x, y) && (inner.Empty() || !inside(inner, x, y))
+	})
+}
+
+// applyVerb picks the pattern and transfer mode for a verb and applies them to every canvas pixel
+// within bounds that lies inside the shape.
+func (p *pictParser) applyVerb(verb int, bounds image.Rectangle, inside func(x, y int) bool) {
+	var (
+		pat  = p.penPattern
+		mode = p.penMode
+	)
+
+	switch verb {
+	case qdVerbErase:
+		pat, mode = p.backPattern, qdModePatCopy
+	case qdVerbInvert:
+		pat, mode = patternBlack, qdModePatXor
+	case qdVerbFill:
+		pat, mode = p.fillPattern, qdModePatCopy
+	}
+
+	var area = bounds.Intersect(p.canvas.Bounds())
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if inside(x, y) && p.clipped(x, y) {
+				p.transfer(x, y, pat, mode)
+			}
+		}
+	}
+}
+
+// transfer combines a pattern with a single canvas pixel. The arithmetic transfer modes are drawn as
+// plain copies.
+func (p *pictParser) transfer(x, y int, pat pattern, mode uint16) {
+	if mode >= 32 {
+		mode = qdModePatCopy
+	}
+
+	var set = pat.set(x, y)
+	if mode&0x04 != 0 { // notPat variants
+		set = !set
+	}
+
+	switch mode & 0x03 {
+	case qdModePatCopy & 0x03:
+		if pat.tile != nil && mode&0x04 == 0 {
+			p.canvas.Set(x, y, pat.at(x, y))
+		} else if set {
+			p.canvas.SetNRGBA(x, y, p.foreground)
+		} else {
+			p.canvas.SetNRGBA(x, y, p.background)
+		}
+	case qdModePatOr & 0x03:
+		if set {
+			p.canvas.SetNRGBA(x, y, p.foreground)
+		}
+	case qdModePatXor & 0x03:
+		if set {
+			var c = p.canvas.NRGBAAt(x, y)
+			if c.A == 0 {
+				// Nothing has been drawn here, so invert the white of a fresh port.
+				c = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF}
+			}
+			p.canvas.SetNRGBA(x, y, color.NRGBA{R: ^c.R, G: ^c.G, B: ^c.B, A: 0xFF})
+		}
+	case qdModePatBic & 0x03:
+		if set {
+			p.canvas.SetNRGBA(x, y, p.background)
+		}
+	}
+}
+
+// lineTo draws a line from the pen location to the given point and moves the pen there. The pen
+// hangs below and to the right of every point along the line.
+func (p *pictParser) lineTo(to image.Point) {
+	var (
+		from = p.penLocation.Sub(p.origin)
+		dest = to.Sub(p.origin)
+	)
+	p.penLocation = to
+
+	if p.penSize.X <= 0 || p.penSize.Y <= 0 {
+		return
+	}
+
+	var bounds = image.Rectangle{Min: from, Max: dest}.Canon()
+	bounds.Max = bounds.Max.Add(p.penSize)
+
+	var mask = image.NewAlpha(bounds)
+	var stamp = func(pt image.Point) {
+		for y := pt.Y; y < pt.Y+p.penSize.Y; y++ {
+			for x := pt.X; x < pt.X+p.penSize.X; x++ {
+				mask.SetAlpha(x, y, color.Alpha{A: 0xFF})
+			}
+		}
+	}
+
+	// Bresenham's line algorithm.
+	var (
+		dx  = abs(dest.X - from.X)
+		dy  = -abs(dest.Y - from.Y)
+		sx  = sign(dest.X - from.X)
+		sy  = sign(dest.Y - from.Y)
+		e   = dx + dy
+		cur = from
+	)
+	for {
+		stamp(cur)
+		if cur == dest {
+			break
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			cur.X += sx
+		}
+		if e2 := 2 * e; e2 <= dx {
+			e += dx
+			cur.Y += sy
+		}
+	}
+
+	p.applyVerb(qdVerbPaint, bounds, func(x, y int) bool {
+		return mask.AlphaAt(x, y).A != 0
+	})
+}
+
+// insideOval reports whether the center of a pixel lies within the oval inscribed in r.
+func insideOval(r image.Rectangle, x, y int) bool {
+	if !image.Pt(x, y).In(r) {
+		return false
+	}
+
+	var (
+		rx = float64(r.Dx()) / 2
+		ry = float64(r.Dy()) / 2
+		nx = (float64(x) + 0.5 - float64(r.Min.X) - rx) / rx
+		ny = (float64(y) + 0.5 - float64(r.Min.Y) - ry) / ry
+	)
+	return nx*nx+ny*ny <= 1
+}
+
+// insideRoundRect reports whether a pixel lies within r once its corners are rounded off by ovals of
+// the given width and height.
+func insideRoundRect(r image.Rectangle, oval image.Point, x, y int) bool {
+	if !image.Pt(x, y).In(r) {
+		return false
+	}
+
+	var w, h = oval.X, oval.Y
+	if w > r.Dx() {
+		w = r.Dx()
+	}
+	if h > r.Dy() {
+		h = r.Dy()
+	}
+	if w <= 0 || h <= 0 {
+		return true
+	}
+
+	// Each corner is a quarter of an oval of the given size tucked into that corner.
+	var corner = image.Rect(r.Min.X, r.Min.Y, r.Min.X+w, r.Min.Y+h)
+	if x >= r.Min.X+w/2 && x < r.Max.X-w/2 {
+		return true
+	}
+	if y >= r.Min.Y+h/2 && y < r.Max.Y-h/2 {
+		return true
+	}
+	if x >= r.Max.X-w/2 {
+		corner = corner.Add(image.Pt(r.Dx()-w, 0))
+	}
+	if y >= r.Max.Y-h/2 {
+		corner = corner.Add(image.Pt(0, r.Dy()-h))
+	}
+	return insideOval(corner, x, y)
+}
+
+// insideArcAngle reports whether a pixel falls within the wedge of an arc. QuickDraw measures angles
+// clockwise from 12 o'clock and scales them to the bounding rectangle, so that 45° always points at
+// its top right corner.
+func insideArcAngle(r image.Rectangle, start int, arc int, x, y int) bool {
+	if arc >= 360 || arc <= -360 {
+		return true
+	}
+	if arc < 0 {
+		start, arc = start+arc, -arc
+	}
+
+	var (
+		nx = (float64(x) + 0.5 - float64(r.Min.X) - float64(r.Dx())/2) / float64(r.Dx())
+		ny = (float64(y) + 0.5 - float64(r.Min.Y) - float64(r.Dy())/2) / float64(r.Dy())
+	)
+
+	var angle = math.Atan2(nx, -ny) * 180 / math.Pi
+	var offset = math.Mod(angle-float64(start), 360)
+	if offset < 0 {
+		offset += 360
+	}
+	return offset < float64(arc)
+}
+
+// insidePolygon tests the center of a pixel against a polygon using the even-odd rule.
+func insidePolygon(points []image.Point, x, y int) bool {
+	var (
+		px     = float64(x) + 0.5
+		py     = float64(y) + 0.5
+		inside = false
+	)
+
+	for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+		var a, b = points[i], points[j]
+		if (float64(a.Y) > py) == (float64(b.Y) > py) {
+			continue
+		}
+		var cross = float64(b.X-a.X)*(py-float64(a.Y))/float64(b.Y-a.Y) + float64(a.X)
+		if px < cross {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// skipReservedOpCode skips the data of an opcode Apple reserved for future use, whose length is
+// implied by its number. It reports false for opcodes outside of the reserved ranges.
+func (p *dataStructureParse) skipReservedOpCode(op PictOpCode) bool {
+	switch {
+	case op >= 0x0017 && op <= 0x0019, op >= 0x003D && op <= 0x003F, op >= 0x004D && op <= 0x004F,
+		op >= 0x005D && op <= 0x005F, op >= 0x007D && op <= 0x007F, op >= 0x008D && op <= 0x008F,
+		op >= 0x00B0 && op <= 0x00CF, op >= 0x8000 && op <= 0x80FF:
+	case op >= 0x0024 && op <= 0x0027, op >= 0x002C && op <= 0x002F, op >= 0x0092 && op <= 0x0097,
+		op >= 0x009C && op <= 0x009F, op >= 0x00A2 && op <= 0x00AF:
+		p.pos += int(p.readWord())
+	case op >= 0x0035 && op <= 0x0037, op >= 0x0045 && op <= 0x0047, op >= 0x0055 && op <= 0x0057:
+		p.pos += 8
+	case op >= 0x0065 && op <= 0x0067:
+		p.pos += 12
+	case op >= 0x006D && op <= 0x006F:
+		p.pos += 4
+	case op >= 0x0075 && op <= 0x0077, op >= 0x0085 && op <= 0x0087:
+		p.pos += int(p.d.GetUint16(p.pos))
+	case op >= 0x00D0 && op <= 0x00FE, op >= 0x8100:
+		p.pos += int(p.readDWord())
+	case op >= 0x0100 && op <= 0x7FFF:
+		p.pos += int(op>>8) * 2
+	default:
+		return false
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,228 @@
+package gomacimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPictFromBytes_QuickDraw(t *testing.T) {
+	var (
+		frame = image.Rect(0, 0, 8, 8)
+		black = color.NRGBA{A: 0xFF}
+		white = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+		red   = color.NRGBA{R: 0xFF, A: 0xFF}
+		none  = color.NRGBA{}
+	)
+
+	tests := []struct {
+		name string
+		ops  [][]byte
+		want map[image.Point]color.NRGBA
+	}{
+		{
+			name: "paint rect",
+			ops: [][]byte{
+				testOp(PictOpCodeRGBFgCol, []uint16{0xFFFF, 0, 0}),
+				testOp(PictOpCodeFrameRect+qdVerbPaint, image.Rect(2, 2, 6, 6)),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 2, Y: 2}: red,
+				{X: 5, Y: 5}: red,
+				{X: 6, Y: 6}: none,
+				{X: 1, Y: 3}: none,
+			},
+		},
+		{
+			name: "frame rect with a wide pen",
+			ops: [][]byte{
+				testOp(PictOpCodePnSize, int16(2), int16(2)),
+				testOp(PictOpCodeFrameRect, frame),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: black,
+				{X: 1, Y: 4}: black,
+				{X: 6, Y: 7}: black,
+				{X: 2, Y: 2}: none,
+				{X: 5, Y: 5}: none,
+			},
+		},
+		{
+			name: "frame rect smaller than the pen",
+			ops: [][]byte{
+				testOp(PictOpCodePnSize, int16(2), int16(2)),
+				testOp(PictOpCodeFrameRect, image.Rect(0, 0, 3, 3)),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: black,
+				{X: 1, Y: 1}: black,
+				{X: 2, Y: 2}: black,
+				{X: 3, Y: 3}: none,
+			},
+		},
+		{
+			name: "erase same rect",
+			ops: [][]byte{
+				testOp(PictOpCodeFrameRect+qdVerbPaint, frame),
+				testOp(PictOpCodeFrameRect+qdVerbPaint, image.Rect(2, 2, 4, 4)),
+				testOp(PictOpCodeFrameSameRect + qdVerbErase),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 1, Y: 1}: black,
+				{X: 2, Y: 2}: white,
+				{X: 3, Y: 3}: white,
+				{X: 4, Y: 4}: black,
+			},
+		},
+		{
+			name: "paint oval",
+			ops: [][]byte{
+				testOp(PictOpCodeFrameOval+qdVerbPaint, frame),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: none,
+				{X: 7, Y: 7}: none,
+				{X: 0, Y: 4}: black,
+				{X: 4, Y: 4}: black,
+			},
+		},
+		{
+			name: "paint round rect",
+			ops: [][]byte{
+				testOp(PictOpCodeOvSize, int16(4), int16(4)),
+				testOp(PictOpCodeFrameRRect+qdVerbPaint, frame),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: none,
+				{X: 7, Y: 0}: none,
+				{X: 1, Y: 1}: black,
+				{X: 3, Y: 0}: black,
+				{X: 0, Y: 4}: black,
+			},
+		},
+		{
+			name: "paint arc",
+			ops: [][]byte{
+				testOp(PictOpCodeFrameArc+qdVerbPaint, frame, int16(0), int16(90)),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 5, Y: 2}: black,
+				{X: 2, Y: 2}: none,
+				{X: 5, Y: 5}: none,
+				{X: 2, Y: 5}: none,
+			},
+		},
+		{
+			name: "invert rect",
+			ops: [][]byte{
+				testOp(PictOpCodeFrameRect+qdVerbPaint, image.Rect(0, 0, 4, 8)),
+				testOp(PictOpCodeFrameRect+qdVerbInvert, frame),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: white,
+				{X: 7, Y: 7}: black,
+			},
+		},
+		{
+			name: "fill rect with a pattern",
+			ops: [][]byte{
+				testOp(PictOpCodeFillPat, []uint8{0xAA, 0x55, 0xAA, 0x55, 0xAA, 0x55, 0xAA, 0x55}),
+				testOp(PictOpCodeFrameRect+qdVerbFill, frame),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: black,
+				{X: 1, Y: 0}: white,
+				{X: 0, Y: 1}: white,
+				{X: 1, Y: 1}: black,
+			},
+		},
+		{
+			name: "lines",
+			ops: [][]byte{
+				testOp(PictOpCodeLine, int16(0), int16(0), int16(3), int16(3)),
+				testOp(PictOpCodeShortLineFrom, int8(4), int8(0)),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: black,
+				{X: 2, Y: 2}: black,
+				{X: 5, Y: 3}: black,
+				{X: 7, Y: 3}: black,
+				{X: 1, Y: 0}: none,
+				{X: 7, Y: 4}: none,
+			},
+		},
+		{
+			name: "clip region",
+			ops: [][]byte{
+				testOp(PictOpCodeClipRegion, []uint16{
+					36, 0, 0, 8, 8,
+					0, 0, 8, 0x7FFF,
+					4, 4, 8, 0x7FFF,
+					8, 0, 4, 0x7FFF,
+					0x7FFF,
+				}),
+				testOp(PictOpCodeFrameRect+qdVerbPaint, frame),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 7, Y: 0}: black,
+				{X: 3, Y: 7}: black,
+				{X: 4, Y: 4}: none,
+				{X: 7, Y: 7}: none,
+			},
+		},
+		{
+			name: "bitmaps after origin",
+			ops: [][]byte{
+				testOp(PictOpCodeOrigin, int16(2), int16(1)),
+				testOp(PictOpCodeBitsRect,
+					uint16(1), image.Rect(0, 0, 4, 1),
+					image.Rect(0, 0, 4, 1), image.Rect(4, 1, 8, 2), uint16(0),
+					[]byte{0xF0}),
+				testPackBitsRectOp(image.Rect(0, 0, 8, 1), image.Rect(0, 0, 8, 1), image.Rect(0, 5, 8, 6), [][]byte{
+					{0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01},
+				}),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 2, Y: 0}: black,
+				{X: 5, Y: 0}: black,
+				{X: 6, Y: 0}: none,
+				{X: 7, Y: 1}: none,
+				{X: 0, Y: 4}: red,
+				{X: 5, Y: 4}: red,
+				{X: 6, Y: 4}: none,
+				{X: 0, Y: 5}: none,
+			},
+		},
+		{
+			name: "paint poly",
+			ops: [][]byte{
+				testOp(PictOpCodeFramePoly+qdVerbPaint, uint16(22), frame,
+					[]int16{0, 0, 0, 8, 8, 0}),
+			},
+			want: map[image.Point]color.NRGBA{
+				{X: 0, Y: 0}: black,
+				{X: 6, Y: 0}: black,
+				{X: 0, Y: 6}: black,
+				{X: 7, Y: 7}: none,
+				{X: 5, Y: 5}: none,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := PictFromBytes(testPict(frame, tt.ops...))
+			if err != nil {
+				t.Fatalf("PictFromBytes() error = %v", err)
+			}
+
+			for pt, want := range tt.want {
+				if c := color.NRGBAModel.Convert(got.At(pt.X, pt.Y)); c != want {
+					t.Errorf("PictFromBytes() [At(%v, %v)] got = %v, want %v", pt.X, pt.Y, c, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package gomacimage
+
+// See Inside Macintosh: QuickTime, Chapter 3 (Image Compression Manager) for the layout of the
+// CompressedQuickTime opcode and the ImageDescription structure.
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+)
+
+const (
+	PictOpCodeCompressedQuickTime PictOpCode = 0x8200
+)
+
+// UnsupportedCodecError is returned when a PICT carries QuickTime compressed image data in a format
+// that cannot be decoded.
+type UnsupportedCodecError struct {
+	Codec string // The four character code of the compressor
+}
+
+func (e UnsupportedCodecError) Error() string {
+	return fmt.Sprintf("unsupported QuickTime codec: '%s'", e.Codec)
+}
+
+type imageDescription struct {
+	size     uint32
+	codec    string
+	width    uint16
+	height   uint16
+	dataSize uint32
+	depth    uint16
+	clutID   uint16
+}
+
+func (p *dataStructureParse) parseImageDescription() imageDescription {
+	var start = p.pos
+	var desc = imageDescription{
+		size:  p.readDWord(),
+		codec: string(p.readDataUint8(4)),
+	}
+
+	p.pos += 4 + 2 + 2 // reserved, reserved, data reference index
+	p.pos += 2 + 2 + 4 // version, revision level, vendor
+	p.pos += 4 + 4     // temporal quality, spatial quality
+
+	desc.width = p.readWord()
+	desc.height = p.readWord()
+
+	p.pos += 4 + 4 // horizontal and vertical resolution
+
+	desc.dataSize = p.readDWord()
+
+	p.pos += 2  // frame count
+	p.pos += 32 // compressor name
+
+	desc.depth = p.readWord()
+	desc.clutID = p.readWord()
+
+	p.pos = start + int(desc.size)
+	return desc
+}
+
+// readFixedMatrix reads a QuickTime transformation matrix, keeping the scale and translation terms.
+func (p *dataStructureParse) readFixedMatrix() [3][3]float64 {
+	var m [3][3]float64
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			var v = float64(int32(p.readDWord()))
+			if col == 2 {
+				m[row][col] = v / (1 << 30) // Fract
+			} else {
+				m[row][col] = v / (1 << 16) // Fixed
+			}
+		}
+	}
+	return m
+}
+
+// parseCompressedQuickTime decodes an image compressed with a QuickTime codec and draws it through
+// its transformation matrix. Only the JPEG codec is supported.
+func (p *pictParser) parseCompressedQuickTime() error {
+	var length = int(p.readDWord())
+	var end = p.pos + length
+
+	p.pos += 2 // version
+	var matrix = p.readFixedMatrix()
+	var matteSize = p.readDWord()
+	var _ = p.readQDRect() // matte rect
+	var _ = p.readWord()   // transfer mode
+	var sourceRect = p.readQDRect().rectangle()
+	p.pos += 4 // accuracy
+	var maskSize = p.readDWord()
+
+	if matteSize > 0 {
+		var matte = p.parseImageDescription()
+		p.pos += int(matte.dataSize)
+	}
+
+	var maskRgn *Region
+	if maskSize > 0 {
+		maskRgn = p.readRegion().translate(p.origin.Mul(-1))
+	}
+
+	var desc = p.parseImageDescription()
+	var dataSize = int(desc.dataSize)
+	if dataSize == 0 || p.pos+dataSize > end {
+		dataSize = end - p.pos
+	}
+	var data = p.readDataUint8(dataSize)
+	p.pos = end
+
+	if desc.codec != "jpeg" {
+		return UnsupportedCodecError{Codec: desc.codec}
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if sourceRect.Empty() {
+		sourceRect = img.Bounds()
+	}
+
+	// The matrix maps the source rect to its place in the picture.
+	var transform = func(x, y int) image.Point {
+		return image.Pt(
+			int(float64(x)*matrix[0][0]+float64(y)*matrix[1][0]+matrix[2][0]+0.5),
+			int(float64(x)*matrix[0][1]+float64(y)*matrix[1][1]+matrix[2][1]+0.5),
+		)
+	}
+	var destinationRect = image.Rectangle{
+		Min: transform(sourceRect.Min.X, sourceRect.Min.Y),
+		Max: transform(sourceRect.Max.X, sourceRect.Max.Y),
+	}.Canon().Sub(p.origin)
+
+	// The compressed image covers the source rect starting from its own origin.
+	var offset = img.Bounds().Min.Sub(sourceRect.Min)
+	p.drawBits(img, sourceRect.Add(offset), destinationRect, maskRgn)
+
+	return nil
+}
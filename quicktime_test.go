@@ -0,0 +1,85 @@
+package gomacimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// testCompressedQuickTimeOp wraps compressed image data in a CompressedQuickTime opcode whose matrix
+// translates the image by the given offset.
+func testCompressedQuickTimeOp(codec string, bounds image.Rectangle, offset image.Point, data []byte) []byte {
+	var desc bytes.Buffer
+	w := func(b *bytes.Buffer, v ...interface{}) {
+		for _, x := range v {
+			_ = binary.Write(b, binary.BigEndian, x)
+		}
+	}
+
+	w(&desc, uint32(86), []byte(codec), uint32(0), uint16(0), uint16(1), uint16(0), uint16(0), uint32(0))
+	w(&desc, uint32(0), uint32(0x200), uint16(bounds.Dx()), uint16(bounds.Dy()), uint32(0x00480000), uint32(0x00480000))
+	w(&desc, uint32(len(data)), uint16(1), make([]byte, 32), uint16(24), int16(-1))
+
+	var body bytes.Buffer
+	w(&body, uint16(0))
+	w(&body, int32(1<<16), int32(0), int32(0))
+	w(&body, int32(0), int32(1<<16), int32(0))
+	w(&body, int32(offset.X<<16), int32(offset.Y<<16), int32(1<<30))
+	w(&body, uint32(0), []int16{0, 0, 0, 0}, uint16(0))
+	w(&body, []int16{int16(bounds.Min.Y), int16(bounds.Min.X), int16(bounds.Max.Y), int16(bounds.Max.X)})
+	w(&body, uint32(0), uint32(0))
+	body.Write(desc.Bytes())
+	body.Write(data)
+
+	return testOp(PictOpCodeCompressedQuickTime, uint32(body.Len()), body.Bytes())
+}
+
+func TestPictFromBytes_CompressedQuickTime(t *testing.T) {
+	frame := image.Rect(0, 0, 24, 12)
+	bounds := image.Rect(0, 0, 16, 8)
+
+	src := image.NewNRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 0xFF, A: 0xFF})
+		}
+	}
+
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, src, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+
+	got, err := PictFromBytes(testPict(frame, testCompressedQuickTimeOp("jpeg", bounds, image.Pt(4, 2), jpegData.Bytes())))
+	if err != nil {
+		t.Fatalf("PictFromBytes() error = %v", err)
+	}
+
+	for _, pt := range []image.Point{{X: 4, Y: 2}, {X: 12, Y: 6}, {X: 19, Y: 9}} {
+		r, g, b, a := got.At(pt.X, pt.Y).RGBA()
+		if r>>8 < 0xF0 || g>>8 > 0x10 || b>>8 > 0x10 || a>>8 != 0xFF {
+			t.Errorf("PictFromBytes() [At(%v, %v)] got = %v, want red", pt.X, pt.Y, got.At(pt.X, pt.Y))
+		}
+	}
+	for _, pt := range []image.Point{{X: 3, Y: 2}, {X: 20, Y: 9}, {X: 12, Y: 10}} {
+		if _, _, _, a := got.At(pt.X, pt.Y).RGBA(); a != 0 {
+			t.Errorf("PictFromBytes() [At(%v, %v)] got = %v, want transparent", pt.X, pt.Y, got.At(pt.X, pt.Y))
+		}
+	}
+}
+
+func TestPictFromBytes_UnsupportedCodec(t *testing.T) {
+	frame := image.Rect(0, 0, 8, 8)
+
+	_, err := PictFromBytes(testPict(frame, testCompressedQuickTimeOp("rle ", frame, image.Point{}, []byte{0, 1, 2, 3})))
+	codecErr, ok := err.(UnsupportedCodecError)
+	if !ok {
+		t.Fatalf("PictFromBytes() error = %v, want UnsupportedCodecError", err)
+	}
+	if codecErr.Codec != "rle " {
+		t.Errorf("UnsupportedCodecError.Codec = %q, want %q", codecErr.Codec, "rle ")
+	}
+}
@@ -0,0 +1,158 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Region is a QuickDraw region decoded into a mask covering its bounding box. It is an image.Image
+// that is opaque inside the region and transparent outside of it, so it can be used directly as the
+// mask of draw.DrawMask.
+type Region struct {
+	bounds image.Rectangle
+	mask   *image.Alpha // nil for rectangular regions
+}
+
+func RegionFromBytes(b []byte) (rgn *Region, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			rgn = nil
+
+			switch x := r.(type) {
+			case string:
+				err = errors.New(x)
+			case error:
+				err = x
+			default:
+				err = errors.New(fmt.Sprintf("unknown panic: %v", r))
+			}
+		}
+	}()
+
+	parser := dataStructureParse{
+		d:   NewBigEndianDataView(b),
+		pos: 0,
+	}
+
+	return parser.readRegion(), nil
+}
+
+func (r *Region) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (r *Region) Bounds() image.Rectangle {
+	return r.bounds
+}
+
+func (r *Region) At(x, y int) color.Color {
+	if r.Contains(x, y) {
+		return color.Opaque
+	}
+	return color.Transparent
+}
+
+// IsRectangular reports whether the region covers the whole of its bounding box.
+func (r *Region) IsRectangular() bool {
+	return r.mask == nil
+}
+
+func (r *Region) Contains(x, y int) bool {
+	if !(image.Point{X: x, Y: y}).In(r.bounds) {
+		return false
+	}
+	if r.mask == nil {
+		return true
+	}
+	return r.mask.AlphaAt(x, y).A != 0
+}
+
+// translate returns a copy of the region moved by the given offset. The mask is shared.
+func (r *Region) translate(offset image.Point) *Region {
+	var moved = &Region{bounds: r.bounds.Add(offset)}
+	if r.mask != nil {
+		moved.mask = &image.Alpha{
+			Pix:    r.mask.Pix,
+			Stride: r.mask.Stride,
+			Rect:   r.mask.Rect.Add(offset),
+		}
+	}
+	return moved
+}
+
+// readRegion reads a QuickDraw region. Non-rectangular regions follow their bounding box with a list
+// of scan lines, each a y coordinate followed by the x coordinates of its inversion points and
+// terminated by 0x7FFF. A pixel lies within the region when an odd number of inversion points have
+// been crossed to the left of it, accumulated over every scan line above it.
+func (p *dataStructureParse) readRegion() *Region {
+	var start = p.pos
+	var size = int(p.readWord())
+	var rect = p.readQDRect()
+	var rgn = &Region{
+		bounds: rect.rectangle(),
+	}
+
+	if size <= 10 {
+		p.pos = start + size
+		return rgn
+	}
+
+	rgn.mask = image.NewAlpha(rgn.bounds)
+
+	var (
+		width     = rgn.bounds.Dx()
+		inversion = make([]bool, width)
+		line      = make([]uint8, width)
+		y         = rgn.bounds.Min.Y
+	)
+
+	fill := func(until int) {
+		if until > rgn.bounds.Max.Y {
+			until = rgn.bounds.Max.Y
+		}
+		for ; y < until; y++ {
+			if y < rgn.bounds.Min.Y {
+				continue
+			}
+			copy(rgn.mask.Pix[rgn.mask.PixOffset(rgn.bounds.Min.X, y):], line)
+		}
+	}
+
+	for p.pos < start+size {
+		var scanY = int(int16(p.readWord()))
+		if scanY == 0x7FFF {
+			break
+		}
+
+		fill(scanY)
+
+		for {
+			var x = int(int16(p.readWord()))
+			if x == 0x7FFF {
+				break
+			}
+			if x -= rgn.bounds.Min.X; x >= 0 && x < width {
+				inversion[x] = !inversion[x]
+			}
+		}
+
+		var inside = false
+		for x := range line {
+			if inversion[x] {
+				inside = !inside
+			}
+			if inside {
+				line[x] = 0xFF
+			} else {
+				line[x] = 0x00
+			}
+		}
+	}
+
+	fill(rgn.bounds.Max.Y)
+
+	p.pos = start + size
+	return rgn
+}
@@ -0,0 +1,109 @@
+package gomacimage
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestDataStructureParse_ReadRegion(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []uint16
+		bounds image.Rectangle
+		inside []image.Point
+		out    []image.Point
+	}{
+		{
+			name:   "rectangle",
+			data:   []uint16{10, 1, 2, 5, 6},
+			bounds: image.Rect(2, 1, 6, 5),
+			inside: []image.Point{{X: 2, Y: 1}, {X: 5, Y: 4}},
+			out:    []image.Point{{X: 1, Y: 1}, {X: 6, Y: 4}, {X: 5, Y: 5}},
+		},
+		{
+			name: "l shape",
+			data: []uint16{
+				36, 0, 0, 4, 4,
+				0, 0, 4, 0x7FFF,
+				2, 2, 4, 0x7FFF,
+				4, 0, 2, 0x7FFF,
+				0x7FFF,
+			},
+			bounds: image.Rect(0, 0, 4, 4),
+			inside: []image.Point{{X: 0, Y: 0}, {X: 3, Y: 1}, {X: 1, Y: 3}},
+			out:    []image.Point{{X: 2, Y: 2}, {X: 3, Y: 3}, {X: 4, Y: 0}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b []byte
+			for _, w := range tt.data {
+				b = append(b, uint8(w>>8), uint8(w))
+			}
+			b = append(b, 0xAB, 0xCD)
+
+			parser := dataStructureParse{d: NewBigEndianDataView(b)}
+			rgn := parser.readRegion()
+
+			if parser.pos != len(b)-2 {
+				t.Errorf("readRegion() consumed %v bytes, want %v", parser.pos, len(b)-2)
+			}
+			if rgn.bounds != tt.bounds {
+				t.Errorf("readRegion() bounds = %v, want %v", rgn.bounds, tt.bounds)
+			}
+			for _, pt := range tt.inside {
+				if !rgn.Contains(pt.X, pt.Y) {
+					t.Errorf("contains(%v) = false, want true", pt)
+				}
+			}
+			for _, pt := range tt.out {
+				if rgn.Contains(pt.X, pt.Y) {
+					t.Errorf("contains(%v) = true, want false", pt)
+				}
+			}
+		})
+	}
+}
+
+func TestRegionFromBytes(t *testing.T) {
+	// A diamond with a one pixel point at the top and bottom.
+	rgn, err := RegionFromBytes([]byte{
+		0x00, 0x2C, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x7F, 0xFF,
+		0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x7F, 0xFF,
+		0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x7F, 0xFF,
+		0x7F, 0xFF,
+	})
+	if err != nil {
+		t.Fatalf("RegionFromBytes() error = %v", err)
+	}
+
+	if rgn.IsRectangular() {
+		t.Errorf("IsRectangular() = true, want false")
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	draw.DrawMask(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, rgn, image.Point{}, draw.Over)
+
+	want := []string{
+		" # ",
+		"###",
+		" # ",
+	}
+	for y, row := range want {
+		for x, c := range row {
+			if got := dst.NRGBAAt(x, y).A != 0; got != (c == '#') {
+				t.Errorf("draw.DrawMask() [At(%v, %v)] drawn = %v, want %v", x, y, got, c == '#')
+			}
+		}
+	}
+
+	if _, err := RegionFromBytes([]byte{0x00, 0x0A}); err == nil {
+		t.Errorf("RegionFromBytes() error = nil, want an error for truncated data")
+	}
+}
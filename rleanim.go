@@ -0,0 +1,233 @@
+package gomacimage
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// DefaultRleFrameDelay is the delay between frames used when no delay is given, matching the 30 frames
+// per second sprites are played back at.
+const DefaultRleFrameDelay = time.Second / 30
+
+// RleAnimationOptions are the encoding parameters for RleEncodeGIF and RleEncodeAPNG.
+type RleAnimationOptions struct {
+	// Delay is how long each frame is shown. It defaults to DefaultRleFrameDelay.
+	Delay time.Duration
+
+	// FirstFrame is the index of the first frame of the animation.
+	FirstFrame int
+
+	// FrameCount is the number of frames in the animation. When zero, every frame from FirstFrame on is
+	// used.
+	FrameCount int
+}
+
+// rleAnimationFrames returns the frames selected by opts along with the delay between them.
+func rleAnimationFrames(r *Rle, opts *RleAnimationOptions) ([]image.Image, time.Duration, error) {
+	var (
+		delay = DefaultRleFrameDelay
+		first = 0
+		count = 0
+	)
+
+	if opts != nil {
+		first, count = opts.FirstFrame, opts.FrameCount
+		if opts.Delay > 0 {
+			delay = opts.Delay
+		}
+	}
+
+	if count == 0 {
+		count = r.FrameCount - first
+	}
+	if first < 0 || count <= 0 || first+count > r.FrameCount {
+		return nil, 0, errors.New(fmt.Sprintf("invalid frame range %v+%v of %v frames", first, count, r.FrameCount))
+	}
+
+	return r.Frames()[first : first+count], delay, nil
+}
+
+// RleEncodeGIF writes the frames of r as a looping animated GIF. The frames share a palette made of
+// their most frequent colors, with every other color mapped to the nearest entry, and pixels with
+// less than half alpha become transparent.
+func RleEncodeGIF(w io.Writer, r *Rle, opts *RleAnimationOptions) error {
+	frames, delay, err := rleAnimationFrames(r, opts)
+	if err != nil {
+		return err
+	}
+
+	// The first entry of the palette is kept for transparent pixels.
+	var (
+		opaque = paletteFromImages(frames, 255)
+		pal    = append(color.Palette{color.Transparent}, opaque...)
+		rect   = image.Rectangle{Max: r.FrameSize}
+		anim   = gif.GIF{
+			Config: image.Config{
+				ColorModel: pal,
+				Width:      r.FrameSize.X,
+				Height:     r.FrameSize.Y,
+			},
+		}
+	)
+
+	var centiseconds = int((delay + 5*time.Millisecond) / (10 * time.Millisecond))
+	if centiseconds < 1 {
+		centiseconds = 1
+	}
+
+	for _, frame := range frames {
+		var (
+			bounds = frame.Bounds()
+			img    = image.NewPaletted(rect, pal)
+		)
+
+		for y := 0; y < rect.Dy(); y++ {
+			for x := 0; x < rect.Dx(); x++ {
+				c := color.NRGBAModel.Convert(frame.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+				if c.A < 0x80 {
+					continue
+				}
+				c.A = 0xFF
+				img.SetColorIndex(x, y, uint8(opaque.Index(c)+1))
+			}
+		}
+
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, centiseconds)
+		anim.Disposal = append(anim.Disposal, gif.DisposalBackground)
+	}
+
+	return gif.EncodeAll(w, &anim)
+}
+
+// RleEncodeAPNG writes the frames of r as a looping animated PNG with 8-bit RGBA pixels, keeping their
+// alpha as is.
+func RleEncodeAPNG(w io.Writer, r *Rle, opts *RleAnimationOptions) error {
+	frames, delay, err := rleAnimationFrames(r, opts)
+	if err != nil {
+		return err
+	}
+
+	var (
+		out      bytes.Buffer
+		sequence = uint32(0)
+		width    = uint32(r.FrameSize.X)
+		height   = uint32(r.FrameSize.Y)
+	)
+
+	writeChunk := func(name string, data ...[]byte) {
+		var length uint32
+		for _, d := range data {
+			length += uint32(len(d))
+		}
+
+		var header [4]byte
+		binary.BigEndian.PutUint32(header[:], length)
+		out.Write(header[:])
+
+		crc := crc32.NewIEEE()
+		crc.Write([]byte(name))
+		out.WriteString(name)
+		for _, d := range data {
+			crc.Write(d)
+			out.Write(d)
+		}
+
+		binary.BigEndian.PutUint32(header[:], crc.Sum32())
+		out.Write(header[:])
+	}
+
+	nextSequence := func() []byte {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], sequence)
+		sequence++
+		return b[:]
+	}
+
+	// Delays are stored as a fraction of a second, here in milliseconds.
+	var delayNum = uint16(0xFFFF)
+	if delay < 0xFFFF*time.Millisecond {
+		delayNum = uint16(delay / time.Millisecond)
+	}
+
+	out.WriteString("\x89PNG\r\n\x1a\n")
+
+	var ihdr dataStructureWrite
+	ihdr.writeDWord(width)
+	ihdr.writeDWord(height)
+	ihdr.writeByte(8) // bit depth
+	ihdr.writeByte(6) // RGBA
+	ihdr.writeByte(0) // deflate
+	ihdr.writeByte(0) // adaptive filtering
+	ihdr.writeByte(0) // no interlace
+	writeChunk("IHDR", ihdr.b.Bytes())
+
+	var actl dataStructureWrite
+	actl.writeDWord(uint32(len(frames)))
+	actl.writeDWord(0) // loop forever
+	writeChunk("acTL", actl.b.Bytes())
+
+	for i, frame := range frames {
+		var fctl dataStructureWrite
+		fctl.writeData(nextSequence())
+		fctl.writeDWord(width)
+		fctl.writeDWord(height)
+		fctl.writeDWord(0) // x offset
+		fctl.writeDWord(0) // y offset
+		fctl.writeWord(delayNum)
+		fctl.writeWord(1000)
+		fctl.writeByte(0) // APNG_DISPOSE_OP_NONE
+		fctl.writeByte(0) // APNG_BLEND_OP_SOURCE
+		writeChunk("fcTL", fctl.b.Bytes())
+
+		data, err := apngFrameData(frame, r.FrameSize)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			writeChunk("IDAT", data)
+		} else {
+			writeChunk("fdAT", nextSequence(), data)
+		}
+	}
+
+	writeChunk("IEND")
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// apngFrameData compresses the pixels of a frame into the image data of a PNG, without filtering.
+func apngFrameData(frame image.Image, size image.Point) ([]byte, error) {
+	var (
+		compressed bytes.Buffer
+		bounds     = frame.Bounds()
+		zw         = zlib.NewWriter(&compressed)
+		row        = make([]byte, 1+4*size.X)
+	)
+
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			c := color.NRGBAModel.Convert(frame.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			copy(row[1+4*x:], []byte{c.R, c.G, c.B, c.A})
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
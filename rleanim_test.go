@@ -0,0 +1,193 @@
+package gomacimage
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func testRleAnimation(t *testing.T) (*Rle, []*image.NRGBA) {
+	var images []image.Image
+	for i := 0; i < 6; i++ {
+		frame := image.NewNRGBA(image.Rect(0, 0, 5, 4))
+		fill := color.NRGBA{R: uint8(i * 0x31), G: 0x84, B: 0xFF - uint8(i*0x21), A: 0xFF}
+		draw.Draw(frame, image.Rect(i%4, 0, i%4+2, 4), image.NewUniform(fill), image.Point{}, draw.Src)
+		images = append(images, frame)
+	}
+
+	b, err := RleEncode(images)
+	if err != nil {
+		t.Fatalf("RleEncode() error = %v", err)
+	}
+
+	r, err := RleFromBytes(b)
+	if err != nil {
+		t.Fatalf("RleFromBytes() error = %v", err)
+	}
+
+	// Compare against the decoded frames, as the colors have been reduced to RGB 555.
+	var frames []*image.NRGBA
+	for _, frame := range r.Frames() {
+		img := image.NewNRGBA(image.Rect(0, 0, 5, 4))
+		draw.Draw(img, img.Bounds(), frame, frame.Bounds().Min, draw.Src)
+		frames = append(frames, img)
+	}
+
+	return r, frames
+}
+
+func TestRleEncodeGIF(t *testing.T) {
+	r, frames := testRleAnimation(t)
+
+	tests := []struct {
+		name  string
+		opts  *RleAnimationOptions
+		first int
+		count int
+		delay int
+	}{
+		{name: "defaults", first: 0, count: 6, delay: 3},
+		{name: "range", opts: &RleAnimationOptions{FirstFrame: 2, FrameCount: 3, Delay: 100 * time.Millisecond}, first: 2, count: 3, delay: 10},
+		{name: "tail", opts: &RleAnimationOptions{FirstFrame: 4}, first: 4, count: 2, delay: 3},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			if err := RleEncodeGIF(&b, r, tt.opts); err != nil {
+				t.Fatalf("RleEncodeGIF() error = %v", err)
+			}
+
+			got, err := gif.DecodeAll(&b)
+			if err != nil {
+				t.Fatalf("gif.DecodeAll() error = %v", err)
+			}
+
+			if len(got.Image) != tt.count {
+				t.Fatalf("gif.DecodeAll() returned %v frames, want %v", len(got.Image), tt.count)
+			}
+
+			for i, img := range got.Image {
+				if got.Delay[i] != tt.delay {
+					t.Errorf("frame %v delay = %v, want %v", i, got.Delay[i], tt.delay)
+				}
+
+				want := frames[tt.first+i]
+				for y := 0; y < 4; y++ {
+					for x := 0; x < 5; x++ {
+						_, _, _, a := img.At(x, y).RGBA()
+						w := want.NRGBAAt(x, y)
+						if w.A == 0 {
+							if a != 0 {
+								t.Errorf("frame %v At(%v, %v) is not transparent", i, x, y)
+							}
+							continue
+						}
+
+						_, _, errs := fuzzyCompImage(img.SubImage(image.Rect(x, y, x+1, y+1)), want.SubImage(image.Rect(x, y, x+1, y+1)))
+						for _, err := range errs {
+							t.Errorf("frame %v: fuzzyCompImage() error = %v", i, err)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRleEncodeAPNG(t *testing.T) {
+	r, frames := testRleAnimation(t)
+
+	var b bytes.Buffer
+	if err := RleEncodeAPNG(&b, r, &RleAnimationOptions{FirstFrame: 1, FrameCount: 4, Delay: 50 * time.Millisecond}); err != nil {
+		t.Fatalf("RleEncodeAPNG() error = %v", err)
+	}
+
+	// Decoders without APNG support show the first frame.
+	first, err := png.Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	_, _, errs := fuzzyCompImage(first, frames[1])
+	for _, err := range errs {
+		t.Errorf("fuzzyCompImage() error = %v", err)
+	}
+
+	// Walk the chunks to check the animation control and the remaining frames.
+	var (
+		data    = b.Bytes()[8:]
+		count   = -1
+		fctls   = 0
+		decoded []*image.NRGBA
+	)
+
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data)
+		name := string(data[4:8])
+		body := data[8 : 8+length]
+		data = data[12+length:]
+
+		switch name {
+		case "acTL":
+			count = int(binary.BigEndian.Uint32(body))
+		case "fcTL":
+			fctls++
+			if num, den := binary.BigEndian.Uint16(body[20:]), binary.BigEndian.Uint16(body[22:]); num != 50 || den != 1000 {
+				t.Errorf("fcTL delay = %v/%v, want 50/1000", num, den)
+			}
+		case "fdAT":
+			zr, err := zlib.NewReader(bytes.NewReader(body[4:]))
+			if err != nil {
+				t.Fatalf("zlib.NewReader() error = %v", err)
+			}
+			raw, err := ioutil.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("ioutil.ReadAll() error = %v", err)
+			}
+
+			img := image.NewNRGBA(image.Rect(0, 0, 5, 4))
+			for y := 0; y < 4; y++ {
+				copy(img.Pix[y*img.Stride:], raw[y*21+1:(y+1)*21])
+			}
+			decoded = append(decoded, img)
+		}
+	}
+
+	if count != 4 || fctls != 4 || len(decoded) != 3 {
+		t.Fatalf("RleEncodeAPNG() wrote %v frames, %v fcTL and %v fdAT chunks, want 4, 4 and 3", count, fctls, len(decoded))
+	}
+
+	for i, img := range decoded {
+		_, _, errs := fuzzyCompImage(img, frames[i+2])
+		for _, err := range errs {
+			t.Errorf("frame %v: fuzzyCompImage() error = %v", i+1, err)
+		}
+	}
+}
+
+func TestRleEncodeGIF_InvalidRange(t *testing.T) {
+	r, _ := testRleAnimation(t)
+
+	for _, opts := range []*RleAnimationOptions{
+		{FirstFrame: -1},
+		{FirstFrame: 6},
+		{FirstFrame: 3, FrameCount: 4},
+	} {
+		if err := RleEncodeGIF(ioutil.Discard, r, opts); err == nil {
+			t.Errorf("RleEncodeGIF(%+v) error = nil, want an error", *opts)
+		}
+		if err := RleEncodeAPNG(ioutil.Discard, r, opts); err == nil {
+			t.Errorf("RleEncodeAPNG(%+v) error = nil, want an error", *opts)
+		}
+	}
+}
@@ -2,8 +2,11 @@ package gomacimage
 
 import (
 	"errors"
+	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
+	"math"
 )
 
 type RleOpCode uint8
@@ -38,14 +41,111 @@ func getRoughDivisor(num uint16) uint16 {
 	return 1
 }
 
+// RleLayout selects how the frames of an rlë resource are arranged on the sprite sheet.
+type RleLayout int
+
+const (
+	// RleLayoutDefault uses a column count that divides evenly into the frame count.
+	RleLayoutDefault RleLayout = iota
+	// RleLayoutRow places every frame in a single row.
+	RleLayoutRow
+	// RleLayoutColumn places every frame in a single column.
+	RleLayoutColumn
+	// RleLayoutSquare uses as many columns as rows, or one more when the frames do not fit.
+	RleLayoutSquare
+)
+
 type Rle struct {
 	Image       image.Image
 	Rectangle   image.Rectangle
 	CountAcross int
 	CountDown   int
+
+	// FrameCount is the number of frames held by the sprite sheet.
+	FrameCount int
+	// FrameSize is the size of a single frame.
+	FrameSize image.Point
 }
 
+// Frame returns the i-th frame as a view into the sprite sheet, or nil when there is no such frame.
+// The bounds of the frame are those it occupies within the sheet.
+func (r *Rle) Frame(i int) image.Image {
+	if i < 0 || i >= r.FrameCount || r.CountAcross == 0 {
+		return nil
+	}
+
+	var min = r.Image.Bounds().Min.Add(image.Pt(i%r.CountAcross*r.FrameSize.X, i/r.CountAcross*r.FrameSize.Y))
+	var rect = image.Rectangle{Min: min, Max: min.Add(r.FrameSize)}
+
+	if sub, ok := r.Image.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+
+	var frame = image.NewNRGBA(rect)
+	draw.Draw(frame, rect, r.Image, rect.Min, draw.Src)
+	return frame
+}
+
+// Frames returns every frame of the sprite sheet, in order.
+func (r *Rle) Frames() []image.Image {
+	var frames = make([]image.Image, r.FrameCount)
+	for i := range frames {
+		frames[i] = r.Frame(i)
+	}
+	return frames
+}
+
+// rleSheetLayout works out the number of frames across and down the sprite sheet.
+func rleSheetLayout(frameCount uint16, opts *RleOptions) (int, int) {
+	var (
+		count  = int(frameCount)
+		across int
+	)
+
+	switch {
+	case opts != nil && opts.Columns > 0:
+		across = opts.Columns
+	case opts == nil || opts.Layout == RleLayoutDefault:
+		divisor := getRoughDivisor(frameCount)
+		return int(divisor), int(frameCount / divisor)
+	case opts.Layout == RleLayoutRow:
+		across = count
+	case opts.Layout == RleLayoutColumn:
+		across = 1
+	case opts.Layout == RleLayoutSquare:
+		across = int(math.Ceil(math.Sqrt(float64(count))))
+	}
+
+	if across < 1 {
+		across = 1
+	}
+	return across, (count + across - 1) / across
+}
+
+// RleOptions are the decoding parameters for RleFromBytesWithOptions.
+type RleOptions struct {
+	// Palette maps the pixels of 8-bit rlë8 resources to colors. It defaults to the Mac OS 256 color
+	// system palette. It is not used for 16-bit rlëD resources.
+	Palette color.Palette
+
+	// Layout arranges the frames on the sprite sheet. It is ignored when Columns is set.
+	Layout RleLayout
+
+	// Columns, when set, fixes the number of frames across the sprite sheet.
+	Columns int
+}
+
+// RleFromBytes decodes a 16-bit rlëD or an 8-bit rlë8 resource into a sprite sheet, using the system
+// palette for rlë8 pixels.
 func RleFromBytes(b []byte) (*Rle, error) {
+	return RleFromBytesWithOptions(b, nil)
+}
+
+// RleFromBytesWithOptions decodes a 16-bit rlëD or an 8-bit rlë8 resource into a sprite sheet. Both
+// share the same opcodes, with counts given in bytes rather than pixels.
+func RleFromBytesWithOptions(b []byte, opts *RleOptions) (*Rle, error) {
 	parser := dataStructureParse{
 		d:   NewBigEndianDataView(b),
 		pos: 0,
@@ -68,17 +168,22 @@ func RleFromBytes(b []byte) (*Rle, error) {
 	// And again there seems to be another run of 6 unused bytes.
 	_ = parser.readDataUint8(6)
 
-	// We're going to assume a colour depth of 16. Anything else will trigger an error.
-	if bitsPerPixel != 16 {
-		return nil, errors.New("invalid color depth in rlëD resource")
+	// Only rlëD (16 bits) and rlë8 (8 bits) resources exist. Anything else will trigger an error.
+	if bitsPerPixel != 16 && bitsPerPixel != 8 {
+		return nil, errors.New(fmt.Sprintf("invalid color depth %v in rlë resource", bitsPerPixel))
 	}
 
-	// Grab a value that divides evenly into the frameCount
-	divisor := getRoughDivisor(frameCount)
+	bytesPerPixel := uint32(bitsPerPixel >> 3)
+
+	var palette color.Palette
+	if opts != nil && opts.Palette != nil {
+		palette = opts.Palette
+	} else if bitsPerPixel == 8 {
+		palette = SystemPalette8
+	}
 
 	// Calculate the sprite sheet layout
-	countAcross := int(divisor)
-	countDown := int(frameCount / divisor)
+	countAcross, countDown := rleSheetLayout(frameCount, opts)
 
 	spriteSheet := image.NewNRGBA(image.Rect(0, 0, width*countAcross, height*countDown))
 
@@ -93,13 +198,29 @@ func RleFromBytes(b []byte) (*Rle, error) {
 	pixel := uint16(0)
 	currentFrame := uint16(0)
 
-	left := int(currentFrame%divisor) * width
-	top := int(currentFrame/divisor) * height
+	left := int(currentFrame) % countAcross * width
+	top := int(currentFrame) / countAcross * height
+
+	writePixel := func(value uint16) error {
+		y, x := int32(top)+currentLine, int32(left)+currentColumn
+		currentColumn++
+
+		if bitsPerPixel == 16 {
+			writePixelData(spriteSheet, y, x, value)
+			return nil
+		}
+
+		if int(value) >= len(palette) {
+			return errors.New(fmt.Sprintf("pixel %v is outside of the palette in rlë8 resource", value))
+		}
+		spriteSheet.Set(int(x), int(y), palette[value])
+		return nil
+	}
 
 	for {
 		position = uint32(parser.pos)
 		if position >= uint32(len(parser.d.buffer)) {
-			return nil, errors.New("early end-of-resource encountered in rlëD resource")
+			return nil, errors.New("early end-of-resource encountered in rlë resource")
 		}
 
 		off := (position - rowStart) & 0x03
@@ -115,7 +236,7 @@ func RleFromBytes(b []byte) (*Rle, error) {
 		switch opCode {
 		case RleOpCodeEndOfFrame:
 			if currentLine != int32(height-1) {
-				return nil, errors.New("incorrect number of scan lines in rlëD resource")
+				return nil, errors.New("incorrect number of scan lines in rlë resource")
 			}
 
 			currentFrame++
@@ -126,11 +247,13 @@ func RleFromBytes(b []byte) (*Rle, error) {
 					Rectangle:   image.Rect(0, 0, width, height),
 					CountAcross: countAcross,
 					CountDown:   countDown,
+					FrameCount:  int(frameCount),
+					FrameSize:   image.Pt(width, height),
 				}, nil
 			}
 
-			left = int(currentFrame%divisor) * width
-			top = int(currentFrame/divisor) * height
+			left = int(currentFrame) % countAcross * width
+			top = int(currentFrame) / countAcross * height
 
 			currentLine = -1
 
@@ -140,10 +263,15 @@ func RleFromBytes(b []byte) (*Rle, error) {
 			rowStart = uint32(parser.pos)
 
 		case RleOpCodePixelData:
-			for i := uint32(0); i < count; i += 2 {
-				pixel = parser.readWord()
-				writePixelData(spriteSheet, int32(top)+currentLine, int32(left)+currentColumn, pixel)
-				currentColumn++
+			for i := uint32(0); i < count; i += bytesPerPixel {
+				if bitsPerPixel == 16 {
+					pixel = parser.readWord()
+				} else {
+					pixel = uint16(parser.readByte())
+				}
+				if err := writePixel(pixel); err != nil {
+					return nil, err
+				}
 			}
 
 			if count&0x03 > 0 {
@@ -151,23 +279,20 @@ func RleFromBytes(b []byte) (*Rle, error) {
 			}
 
 		case RleOpCodeTransparentRun:
-			currentColumn += int32(count >> ((bitsPerPixel >> 3) - 1))
+			currentColumn += int32(count / bytesPerPixel)
 
 		case RleOpCodePixelRun:
-			_ = parser.readDWord()
-
-			for i := uint32(0); i < count; i += 4 {
-				writePixelData(spriteSheet, int32(top)+currentLine, int32(left)+currentColumn, pixel)
-				currentColumn++
+			// The run's pixel is held in the leading bytes of the following long word.
+			pixel = uint16(parser.readDWord() >> (32 - bitsPerPixel))
 
-				if i+2 < count {
-					writePixelData(spriteSheet, int32(top)+currentLine, int32(left)+currentColumn, pixel)
-					currentColumn++
+			for i := uint32(0); i < count; i += bytesPerPixel {
+				if err := writePixel(pixel); err != nil {
+					return nil, err
 				}
 			}
 
 		default:
-			return nil, errors.New("invalid opcode encountered in rlëD resource")
+			return nil, errors.New("invalid opcode encountered in rlë resource")
 		}
 	}
 }
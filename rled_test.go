@@ -3,6 +3,8 @@ package gomacimage
 import (
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/png"
 	"io/ioutil"
 	"os"
@@ -17,6 +19,7 @@ func TestRleFromBytes(t *testing.T) {
 		{name: "1010"},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
@@ -64,3 +67,137 @@ func TestRleFromBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestRleFromBytes_8Bit(t *testing.T) {
+	var w dataStructureWrite
+	w.writeWord(4)
+	w.writeWord(2)
+	w.writeWord(8)
+	w.writeWord(0)
+	w.writeWord(1)
+	w.writeData(make([]byte, 6))
+
+	w.writeDWord(uint32(RleOpCodeLineStart)<<24 | 8)
+	w.writeDWord(uint32(RleOpCodePixelData)<<24 | 3)
+	w.writeData([]byte{0, 5, 255, 0})
+	w.writeDWord(uint32(RleOpCodeLineStart)<<24 | 12)
+	w.writeDWord(uint32(RleOpCodeTransparentRun)<<24 | 1)
+	w.writeDWord(uint32(RleOpCodePixelRun)<<24 | 3)
+	w.writeDWord(0x03030303)
+	w.writeDWord(uint32(RleOpCodeEndOfFrame) << 24)
+
+	system := macSystemPalette8()
+	custom := make(color.Palette, 256)
+	for i := range custom {
+		custom[i] = color.NRGBA{R: uint8(i), G: uint8(255 - i), A: 0xFF}
+	}
+
+	tests := []struct {
+		name string
+		opts *RleOptions
+		pal  color.Palette
+	}{
+		{name: "system palette", pal: system},
+		{name: "custom palette", opts: &RleOptions{Palette: custom}, pal: custom},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := RleFromBytesWithOptions(w.b.Bytes(), tt.opts)
+			if err != nil {
+				t.Fatalf("RleFromBytesWithOptions() error = %v", err)
+			}
+
+			want := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+			want.Set(0, 0, tt.pal[0])
+			want.Set(1, 0, tt.pal[5])
+			want.Set(2, 0, tt.pal[255])
+			for x := 1; x < 4; x++ {
+				want.Set(x, 1, tt.pal[3])
+			}
+
+			_, _, errs := fuzzyCompImage(got.Image, want)
+			for _, err := range errs {
+				t.Errorf("fuzzyCompImage() error = %v", err)
+			}
+		})
+	}
+
+	if _, err := RleFromBytesWithOptions(w.b.Bytes(), &RleOptions{Palette: custom[:4]}); err == nil {
+		t.Errorf("RleFromBytesWithOptions() error = nil, want an error for pixels outside of the palette")
+	}
+}
+
+func TestRle_Frames(t *testing.T) {
+	var frames []image.Image
+	for i := 0; i < 5; i++ {
+		frame := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+		draw.Draw(frame, frame.Bounds(), image.NewUniform(color.NRGBA{R: uint8(i * 0x21), G: 0xFF, A: 0xFF}), image.Point{}, draw.Src)
+		frames = append(frames, frame)
+	}
+
+	b, err := RleEncode(frames)
+	if err != nil {
+		t.Fatalf("RleEncode() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   *RleOptions
+		across int
+		down   int
+	}{
+		{name: "default", across: 1, down: 5},
+		{name: "row", opts: &RleOptions{Layout: RleLayoutRow}, across: 5, down: 1},
+		{name: "column", opts: &RleOptions{Layout: RleLayoutColumn}, across: 1, down: 5},
+		{name: "square", opts: &RleOptions{Layout: RleLayoutSquare}, across: 3, down: 2},
+		{name: "columns", opts: &RleOptions{Layout: RleLayoutRow, Columns: 2}, across: 2, down: 3},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := RleFromBytesWithOptions(b, tt.opts)
+			if err != nil {
+				t.Fatalf("RleFromBytesWithOptions() error = %v", err)
+			}
+
+			if got.CountAcross != tt.across || got.CountDown != tt.down {
+				t.Errorf("RleFromBytesWithOptions() layout = %vx%v, want %vx%v", got.CountAcross, got.CountDown, tt.across, tt.down)
+			}
+			if size := got.Image.Bounds().Size(); size != image.Pt(3*tt.across, 2*tt.down) {
+				t.Errorf("RleFromBytesWithOptions() sheet size = %v, want %v", size, image.Pt(3*tt.across, 2*tt.down))
+			}
+			if got.FrameCount != 5 || got.FrameSize != image.Pt(3, 2) {
+				t.Errorf("RleFromBytesWithOptions() frames = %v of %v, want 5 of %v", got.FrameCount, got.FrameSize, image.Pt(3, 2))
+			}
+
+			all := got.Frames()
+			if len(all) != len(frames) {
+				t.Fatalf("Frames() returned %v frames, want %v", len(all), len(frames))
+			}
+
+			for i, frame := range all {
+				if frame.Bounds().Size() != got.FrameSize {
+					t.Errorf("Frames()[%v] size = %v, want %v", i, frame.Bounds().Size(), got.FrameSize)
+				}
+
+				want := frames[i].At(0, 0)
+				for y := frame.Bounds().Min.Y; y < frame.Bounds().Max.Y; y++ {
+					for x := frame.Bounds().Min.X; x < frame.Bounds().Max.X; x++ {
+						if c := frame.At(x, y); c != want {
+							t.Errorf("Frames()[%v].At(%v, %v) = %v, want %v", i, x, y, c, want)
+						}
+					}
+				}
+			}
+
+			if got.Frame(-1) != nil || got.Frame(5) != nil {
+				t.Errorf("Frame() returned a frame outside of the frame count")
+			}
+		})
+	}
+}
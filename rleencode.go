@@ -0,0 +1,116 @@
+package gomacimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Runs of at least this many identical pixels are written as a PixelRun rather than as pixel data.
+const rleMinPixelRun = 4
+
+// RleEncode builds a 16-bit rlëD resource from a list of equally sized frames. Pixels with less than
+// half alpha become transparent runs, and the remaining pixels are written greedily as pixel runs
+// wherever enough of them repeat, or as pixel data otherwise. Every opcode is kept on a 4 byte
+// boundary.
+func RleEncode(frames []image.Image) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("cannot encode an rlëD resource without frames")
+	}
+	if len(frames) > 0xFFFF {
+		return nil, errors.New("too many frames for an rlëD resource")
+	}
+
+	var size = frames[0].Bounds().Size()
+	if size.X <= 0 || size.Y <= 0 || size.X > 0xFFFF || size.Y > 0xFFFF {
+		return nil, errors.New(fmt.Sprintf("invalid rlëD frame size: %v", size))
+	}
+
+	var out dataStructureWrite
+
+	out.writeWord(uint16(size.X))
+	out.writeWord(uint16(size.Y))
+	out.writeWord(16)
+	out.writeWord(0)
+	out.writeWord(uint16(len(frames)))
+	out.writeData(make([]byte, 6))
+
+	for i, frame := range frames {
+		var bounds = frame.Bounds()
+		if bounds.Size() != size {
+			return nil, errors.New(fmt.Sprintf("frame %v is %v, expected %v", i, bounds.Size(), size))
+		}
+
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			var line = encodeRleLine(frame, bounds.Min.X, y, size.X)
+			writeRleOpCode(&out, RleOpCodeLineStart, uint32(len(line)))
+			out.writeData(line)
+		}
+
+		writeRleOpCode(&out, RleOpCodeEndOfFrame, 0)
+	}
+
+	return out.b.Bytes(), nil
+}
+
+func writeRleOpCode(out *dataStructureWrite, op RleOpCode, count uint32) {
+	out.writeDWord(uint32(op)<<24 | count&0x00FFFFFF)
+}
+
+// encodeRleLine encodes the opcodes of a single scan line. Trailing transparent pixels are dropped.
+func encodeRleLine(frame image.Image, left int, y int, width int) []uint8 {
+	var (
+		out     dataStructureWrite
+		pixels  = make([]uint16, width)
+		opaque  = make([]bool, width)
+		pending []uint16
+	)
+
+	for x := 0; x < width; x++ {
+		c := color.NRGBAModel.Convert(frame.At(left+x, y)).(color.NRGBA)
+		opaque[x] = c.A >= 0x80
+		pixels[x] = uint16(c.R>>3)<<10 | uint16(c.G>>3)<<5 | uint16(c.B>>3)
+	}
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		writeRleOpCode(&out, RleOpCodePixelData, uint32(2*len(pending)))
+		for _, px := range pending {
+			out.writeWord(px)
+		}
+		if len(pending)%2 == 1 {
+			out.writeWord(0)
+		}
+		pending = pending[:0]
+	}
+
+	for x := 0; x < width; {
+		var run = 1
+		for x+run < width && opaque[x+run] == opaque[x] && (!opaque[x] || pixels[x+run] == pixels[x]) {
+			run++
+		}
+
+		switch {
+		case !opaque[x]:
+			flush()
+			if x+run < width {
+				writeRleOpCode(&out, RleOpCodeTransparentRun, uint32(2*run))
+			}
+		case run >= rleMinPixelRun:
+			flush()
+			writeRleOpCode(&out, RleOpCodePixelRun, uint32(2*run))
+			out.writeDWord(uint32(pixels[x])<<16 | uint32(pixels[x]))
+		default:
+			pending = append(pending, pixels[x:x+run]...)
+		}
+
+		x += run
+	}
+
+	flush()
+
+	return out.b.Bytes()
+}
@@ -0,0 +1,121 @@
+package gomacimage
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRleEncode(t *testing.T) {
+	// Colors with their low bits replicated from the high bits survive the trip through RGB 555.
+	expand := func(v uint8) uint8 {
+		v &= 0xF8
+		return v | v>>5
+	}
+
+	frame := func(seed int) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, 13, 7))
+		for y := 0; y < 7; y++ {
+			for x := 0; x < 13; x++ {
+				switch {
+				case y == 0 || x >= 11 || (x+y+seed)%5 == 0:
+					// Leave the pixel transparent.
+				case y%2 == 1 && x < 8:
+					img.SetNRGBA(x, y, color.NRGBA{R: expand(uint8(seed * 40)), G: expand(0x80), B: expand(0xF0), A: 0xFF})
+				default:
+					img.SetNRGBA(x, y, color.NRGBA{R: expand(uint8(x * 20)), G: expand(uint8(y * 30)), B: expand(uint8(seed * 50)), A: 0xFF})
+				}
+			}
+		}
+		return img
+	}
+
+	tests := []struct {
+		name   string
+		frames []image.Image
+	}{
+		{name: "single frame", frames: []image.Image{frame(1)}},
+		{name: "several frames", frames: []image.Image{frame(1), frame(2), frame(3), frame(4)}},
+		{name: "transparent", frames: []image.Image{image.NewNRGBA(image.Rect(0, 0, 5, 5))}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := RleEncode(tt.frames)
+			if err != nil {
+				t.Fatalf("RleEncode() error = %v", err)
+			}
+
+			got, err := RleFromBytes(b)
+			if err != nil {
+				t.Fatalf("RleFromBytes() error = %v", err)
+			}
+
+			for i, want := range tt.frames {
+				offset := image.Pt(i%got.CountAcross*got.Rectangle.Dx(), i/got.CountAcross*got.Rectangle.Dy())
+				sub := image.NewNRGBA(got.Rectangle)
+				draw.Draw(sub, sub.Bounds(), got.Image, offset, draw.Src)
+
+				_, _, errs := fuzzyCompImage(sub, want)
+				for _, err := range errs {
+					t.Errorf("frame %v: fuzzyCompImage() error = %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRleEncode_Errors(t *testing.T) {
+	if _, err := RleEncode(nil); err == nil {
+		t.Errorf("RleEncode() error = nil, want an error without frames")
+	}
+
+	frames := []image.Image{
+		image.NewNRGBA(image.Rect(0, 0, 4, 4)),
+		image.NewNRGBA(image.Rect(0, 0, 4, 5)),
+	}
+	if _, err := RleEncode(frames); err == nil {
+		t.Errorf("RleEncode() error = nil, want an error for frames of different sizes")
+	}
+}
+
+func TestRleEncode_Fixtures(t *testing.T) {
+	for _, name := range []string{"1006", "1010"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			binaryData, err := ioutil.ReadFile(fmt.Sprintf("test/fixtures/rle/%s.bin", name))
+			if err != nil {
+				t.Fatalf("ioutil.ReadFile() error = %v", err)
+			}
+
+			want, err := RleFromBytes(binaryData)
+			if err != nil {
+				t.Fatalf("RleFromBytes() error = %v", err)
+			}
+
+			frames := want.Frames()
+
+			b, err := RleEncode(frames)
+			if err != nil {
+				t.Fatalf("RleEncode() error = %v", err)
+			}
+
+			got, err := RleFromBytes(b)
+			if err != nil {
+				t.Fatalf("RleFromBytes() error = %v", err)
+			}
+
+			_, _, errs := fuzzyCompImage(got.Image, want.Image)
+			for _, err := range errs {
+				t.Errorf("fuzzyCompImage() error = %v", err)
+			}
+		})
+	}
+}